@@ -0,0 +1,186 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ash-xyz/spotify/client"
+	chi "github.com/go-chi/chi/v5"
+)
+
+var accountName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// account is one of the SPOTIFY_ACCOUNTS served at /api/users/{name}, with its
+// own client and cache so accounts never see each other's data
+type account struct {
+	name   string
+	client *client.SpotifyClient
+	cache  *responseCache
+	// refresh is how often the /api payload is fetched in the background, 0 only fetches on request
+	refresh time.Duration
+}
+
+// accountEnv returns the SPOTIFY_ACCOUNT_<NAME>_<setting> variable, "team-a" becomes TEAM_A
+func accountEnv(name string, setting string) string {
+	return "SPOTIFY_ACCOUNT_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_" + setting
+}
+
+// accountsFromEnv reads the comma separated SPOTIFY_ACCOUNTS. Each account is
+// configured with SPOTIFY_ACCOUNT_<NAME>_REFRESH_TOKEN, _LIMIT, _TIME_RANGE,
+// _REFRESH_INTERVAL and _TOKEN_STORE. Without a token store of its own, an
+// account shares defaultTokenStore when it's an env or secrets file, keeping
+// its token in its own variable
+func accountsFromEnv(defaultTokenStore string, opts ...func(*client.Options)) ([]*account, error) {
+	var accounts []*account
+	seen := map[string]bool{}
+
+	for _, name := range strings.Split(os.Getenv("SPOTIFY_ACCOUNTS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !accountName.MatchString(name) {
+			return nil, fmt.Errorf("invalid account name %q, use lowercase letters, digits, - and _", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("account %q is listed twice", name)
+		}
+		seen[name] = true
+
+		acc, err := accountFromEnv(name, defaultTokenStore, opts)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", name, err)
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, nil
+}
+
+func accountFromEnv(name string, defaultTokenStore string, opts []func(*client.Options)) (*account, error) {
+	refreshTokenEnv := accountEnv(name, "REFRESH_TOKEN")
+	clientOptions := append(slices.Clone(opts), client.WithRefreshToken(os.Getenv(refreshTokenEnv)))
+
+	storeSetting := accountEnv(name, "TOKEN_STORE")
+	spec := os.Getenv(storeSetting)
+	if spec == "" && (strings.HasPrefix(defaultTokenStore, "env:") || strings.HasPrefix(defaultTokenStore, "secrets:")) {
+		spec = defaultTokenStore
+	}
+	tokenStore, err := parseTokenStore(storeSetting, spec, refreshTokenEnv)
+	if err != nil {
+		return nil, err
+	}
+	if tokenStore != nil {
+		clientOptions = append(clientOptions, client.WithTokenStore(tokenStore))
+	}
+
+	if limit := os.Getenv(accountEnv(name, "LIMIT")); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", accountEnv(name, "LIMIT"))
+		}
+		clientOptions = append(clientOptions, client.WithLimit(n))
+	}
+
+	if timeRange := os.Getenv(accountEnv(name, "TIME_RANGE")); timeRange != "" {
+		parsed, ok := client.ParseTimeRange(timeRange)
+		if !ok {
+			return nil, fmt.Errorf("%s must be one of short_term, medium_term or long_term", accountEnv(name, "TIME_RANGE"))
+		}
+		clientOptions = append(clientOptions, client.WithTimeRange(parsed))
+	}
+
+	refresh, err := time.ParseDuration(cmp.Or(os.Getenv(accountEnv(name, "REFRESH_INTERVAL")), "3m"))
+	if err != nil || refresh < 0 {
+		return nil, fmt.Errorf("%s must be a duration like 3m, or 0 to disable", accountEnv(name, "REFRESH_INTERVAL"))
+	}
+	// Spotify rate limits the whole app, so every account refreshing often adds up
+	if refresh != 0 && refresh < 30*time.Second {
+		return nil, fmt.Errorf("%s must be at least 30s", accountEnv(name, "REFRESH_INTERVAL"))
+	}
+
+	acc := &account{
+		name:    name,
+		client:  client.NewSpotifyClient(clientOptions...),
+		cache:   newResponseCache(),
+		refresh: refresh,
+	}
+	if !acc.client.HasToken() {
+		return nil, fmt.Errorf("%s is not set", refreshTokenEnv)
+	}
+	return acc, nil
+}
+
+// refreshLoop keeps the default /api payload cached. Start times are spread
+// across the interval so accounts don't all hit Spotify at once
+func (a *account) refreshLoop(ctx context.Context) {
+	if a.refresh == 0 {
+		return
+	}
+
+	select {
+	case <-time.After(rand.N(a.refresh)):
+	case <-ctx.Done():
+		return
+	}
+
+	ticker := time.NewTicker(a.refresh)
+	defer ticker.Stop()
+
+	for {
+		a.refreshNow(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *account) refreshNow(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	query := apiQuery{}
+	data, err := fetchSpotifyDataAsJSON(a.client, ctx, query)
+	if err != nil {
+		log.Printf("Error refreshing account %s: %v", a.name, err)
+		return
+	}
+
+	// Outlives the next refresh, but a failing account's data still expires
+	a.cache.set("api:"+query.cacheKey(), data, max(apiCacheTTL, 2*a.refresh))
+}
+
+// accountRoutes serve each account's /api payload and queue, /api/users lists the accounts
+func accountRoutes(accounts []*account) func(r chi.Router) {
+	names := make([]string, len(accounts))
+	for i, acc := range accounts {
+		names[i] = acc.name
+	}
+	list, _ := json.MarshalIndent(map[string][]string{"users": names}, "", "  ")
+
+	return func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(list)
+		})
+
+		for _, acc := range accounts {
+			r.Route("/"+acc.name, func(r chi.Router) {
+				r.Get("/", apiHandler(acc.client, acc.cache))
+				r.Get("/queue", queueHandler(acc.client, acc.cache))
+			})
+		}
+	}
+}
@@ -0,0 +1,342 @@
+// Package auth gets a Spotify refresh token with the authorization code flow,
+// either through a local callback server or by pasting the redirect URL
+package auth
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ash-xyz/spotify/client"
+	"github.com/pkg/browser"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/spotify"
+)
+
+const (
+	DefaultRedirectHost = "localhost"
+	DefaultRedirectPort = "8888"
+	DefaultRedirectPath = "/callback"
+)
+
+type Options struct {
+	ClientID     string
+	ClientSecret string
+	// TokenURL overrides the OAuth2 token endpoint the code is exchanged at
+	TokenURL     string
+	RedirectHost string
+	RedirectPort string
+	RedirectPath string
+	Scopes       []string
+	// PKCE authenticates without the client secret, it's always used when ClientSecret is empty
+	PKCE bool
+	// NoBrowser prints the auth URL and reads the redirect URL from Input,
+	// instead of opening a browser and listening for the callback
+	NoBrowser bool
+	Input     io.Reader
+	Output    io.Writer
+}
+
+func WithClientID(clientID string) func(*Options) {
+	return func(o *Options) {
+		o.ClientID = clientID
+	}
+}
+
+func WithClientSecret(clientSecret string) func(*Options) {
+	return func(o *Options) {
+		o.ClientSecret = clientSecret
+	}
+}
+
+func WithRedirectHost(host string) func(*Options) {
+	return func(o *Options) {
+		o.RedirectHost = host
+	}
+}
+
+func WithRedirectPort(port string) func(*Options) {
+	return func(o *Options) {
+		o.RedirectPort = port
+	}
+}
+
+func WithRedirectPath(path string) func(*Options) {
+	return func(o *Options) {
+		o.RedirectPath = path
+	}
+}
+
+func WithScopes(scopes ...string) func(*Options) {
+	return func(o *Options) {
+		o.Scopes = scopes
+	}
+}
+
+func WithPKCE() func(*Options) {
+	return func(o *Options) {
+		o.PKCE = true
+	}
+}
+
+func WithNoBrowser() func(*Options) {
+	return func(o *Options) {
+		o.NoBrowser = true
+	}
+}
+
+// UsesPKCE reports whether the token will belong to the public client, in which
+// case it has to be refreshed without the client secret
+func (o *Options) UsesPKCE() bool {
+	return o.PKCE || o.ClientSecret == ""
+}
+
+func (o *Options) callbackPath() string {
+	if !strings.HasPrefix(o.RedirectPath, "/") {
+		return "/" + o.RedirectPath
+	}
+	return o.RedirectPath
+}
+
+func (o *Options) redirectURL() string {
+	return (&url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(o.RedirectHost, o.RedirectPort),
+		Path:   o.callbackPath(),
+	}).String()
+}
+
+// NewOptions reads the defaults from the environment, SPOTIFY_AUTH_SCOPES
+// defaults to the scopes of every feature
+func NewOptions(opts ...func(*Options)) *Options {
+	options := &Options{
+		ClientID:     os.Getenv("SPOTIFY_CLIENT_ID"),
+		ClientSecret: os.Getenv("SPOTIFY_CLIENT_SECRET"),
+		TokenURL:     os.Getenv("SPOTIFY_TOKEN_URL"),
+		RedirectHost: cmp.Or(os.Getenv("SPOTIFY_REDIRECT_HOST"), DefaultRedirectHost),
+		RedirectPort: cmp.Or(os.Getenv("SPOTIFY_REDIRECT_PORT"), DefaultRedirectPort),
+		RedirectPath: cmp.Or(os.Getenv("SPOTIFY_REDIRECT_PATH"), DefaultRedirectPath),
+		Scopes:       client.ScopesFor(client.Features...),
+		Input:        os.Stdin,
+		Output:       os.Stdout,
+	}
+	if scopes := os.Getenv("SPOTIFY_AUTH_SCOPES"); scopes != "" {
+		options.Scopes = client.ResolveScopes(scopes)
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// Login runs the authorization code flow and returns the token Spotify hands out
+func Login(ctx context.Context, options *Options) (*oauth2.Token, error) {
+	if options.ClientID == "" {
+		return nil, errors.New("missing SPOTIFY_CLIENT_ID")
+	}
+	if len(options.Scopes) == 0 {
+		return nil, errors.New("no scopes requested")
+	}
+
+	endpoint := spotify.Endpoint
+	if options.TokenURL != "" {
+		endpoint.TokenURL = options.TokenURL
+	}
+
+	cfg := &oauth2.Config{
+		ClientID:     options.ClientID,
+		ClientSecret: options.ClientSecret,
+		RedirectURL:  options.redirectURL(),
+		Scopes:       options.Scopes,
+		Endpoint:     endpoint,
+	}
+
+	authOpts := []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("show_dialog", "true")}
+	var exchangeOpts []oauth2.AuthCodeOption
+	if options.UsesPKCE() {
+		// Tokens from the PKCE flow belong to the public client and are
+		// refreshed with just the client ID
+		cfg.ClientSecret = ""
+		cfg.Endpoint.AuthStyle = oauth2.AuthStyleInParams
+
+		verifier := oauth2.GenerateVerifier()
+		authOpts = append(authOpts, oauth2.S256ChallengeOption(verifier))
+		exchangeOpts = append(exchangeOpts, oauth2.VerifierOption(verifier))
+	}
+
+	state, err := generateStateToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate state(csrf) token: %w", err)
+	}
+	authURL := cfg.AuthCodeURL(state, authOpts...)
+
+	exchange := func(code string) (*oauth2.Token, error) {
+		token, err := cfg.Exchange(ctx, code, exchangeOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("error exchanging code for token: %w", err)
+		}
+		return token, nil
+	}
+
+	if options.NoBrowser {
+		return loginManually(ctx, options, authURL, state, exchange)
+	}
+	return loginWithBrowser(ctx, options, authURL, state, exchange)
+}
+
+// loginWithBrowser opens the auth URL and waits for Spotify to redirect back
+// to the local callback server
+func loginWithBrowser(ctx context.Context, options *Options, authURL string, state string, exchange func(code string) (*oauth2.Token, error)) (*oauth2.Token, error) {
+	// Listen before opening the browser so the redirect can't beat us
+	listener, err := net.Listen("tcp", ":"+options.RedirectPort)
+	if err != nil {
+		return nil, fmt.Errorf("error starting callback server: %w", err)
+	}
+
+	type result struct {
+		token *oauth2.Token
+		err   error
+	}
+	results := make(chan result, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(options.callbackPath(), func(w http.ResponseWriter, r *http.Request) {
+		// A request without our state didn't come from this login, keep waiting
+		if r.FormValue("state") != state {
+			http.Error(w, "State token mismatch", http.StatusBadRequest)
+			return
+		}
+
+		token, err := callbackToken(r.FormValue("error"), r.FormValue("code"), exchange)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintf(w, "Authentication successful! Feel free to close this window.")
+		}
+
+		select {
+		case results <- result{token, err}:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := browser.OpenURL(authURL); err != nil {
+		return nil, fmt.Errorf("error opening browser, try again with --no-browser: %w", err)
+	}
+
+	select {
+	case res := <-results:
+		return res.token, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loginManually is for machines without a browser, e.g. over SSH. The
+// redirect fails to load on the machine with the browser, but its URL still
+// holds the code
+func loginManually(ctx context.Context, options *Options, authURL string, state string, exchange func(code string) (*oauth2.Token, error)) (*oauth2.Token, error) {
+	fmt.Fprintln(options.Output, "Open this URL in a browser and authorize the app:")
+	fmt.Fprintln(options.Output)
+	fmt.Fprintln(options.Output, authURL)
+	fmt.Fprintln(options.Output)
+	fmt.Fprintln(options.Output, "You'll be redirected to a page that doesn't load, paste its URL (or just the code) here:")
+
+	// The read can't be interrupted, so it's left behind when ctx is done
+	type line struct {
+		input string
+		err   error
+	}
+	lines := make(chan line, 1)
+	go func() {
+		input, err := bufio.NewReader(options.Input).ReadString('\n')
+		lines <- line{input, err}
+	}()
+
+	var input string
+	select {
+	case l := <-lines:
+		if l.err != nil && l.input == "" {
+			return nil, fmt.Errorf("failed to read redirect URL: %w", l.err)
+		}
+		input = l.input
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	code, err := parseRedirect(strings.TrimSpace(input), state)
+	if err != nil {
+		return nil, err
+	}
+	return exchange(code)
+}
+
+func callbackToken(authErr string, code string, exchange func(code string) (*oauth2.Token, error)) (*oauth2.Token, error) {
+	if authErr != "" {
+		return nil, fmt.Errorf("authorization failed: %s", authErr)
+	}
+	if code == "" {
+		return nil, errors.New("couldn't get code required for token exchange")
+	}
+	return exchange(code)
+}
+
+// parseRedirect returns the code from a pasted redirect URL, checking its
+// state, or the input itself when only the code was pasted
+func parseRedirect(input string, state string) (string, error) {
+	if input == "" {
+		return "", errors.New("no redirect URL or code given")
+	}
+
+	if !strings.Contains(input, "?") {
+		return input, nil
+	}
+
+	redirect, err := url.Parse(input)
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect URL: %w", err)
+	}
+	query := redirect.Query()
+
+	if query.Get("state") != state {
+		return "", errors.New("state token mismatch")
+	}
+	if authErr := query.Get("error"); authErr != "" {
+		return "", fmt.Errorf("authorization failed: %s", authErr)
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return "", errors.New("redirect URL has no code")
+	}
+	return code, nil
+}
+
+func generateStateToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
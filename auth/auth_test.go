@@ -0,0 +1,39 @@
+package auth
+
+import "testing"
+
+func TestParseRedirect(t *testing.T) {
+	const state = "expected-state"
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"bare code", "AQBcode", "AQBcode", false},
+		{"redirect URL", "http://localhost:8888/callback?code=AQBcode&state=expected-state", "AQBcode", false},
+		{"state first", "http://localhost:8888/callback?state=expected-state&code=AQBcode", "AQBcode", false},
+		{"escaped code", "http://localhost:8888/callback?code=a%2Fb&state=expected-state", "a/b", false},
+		{"empty", "", "", true},
+		{"wrong state", "http://localhost:8888/callback?code=AQBcode&state=forged", "", true},
+		{"missing state", "http://localhost:8888/callback?code=AQBcode", "", true},
+		{"access denied", "http://localhost:8888/callback?error=access_denied&state=expected-state", "", true},
+		// An error from a login we didn't start isn't reported as ours
+		{"access denied with wrong state", "http://localhost:8888/callback?error=access_denied&state=forged", "", true},
+		{"missing code", "http://localhost:8888/callback?state=expected-state", "", true},
+		{"invalid URL", "http://[::1/callback?code=AQBcode&state=expected-state", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRedirect(tt.input, state)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseRedirect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
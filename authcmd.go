@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ash-xyz/spotify/auth"
+	"github.com/ash-xyz/spotify/client"
+	"github.com/ash-xyz/spotify/internal/secrets"
+	"golang.org/x/oauth2"
+)
+
+// authCommand is `spotify auth`, which gets a new refresh token and saves it
+// locally, or as a Fly.io secret with --prod
+func authCommand(args []string) error {
+	if len(args) > 0 && args[0] == "status" {
+		return authStatusCommand(args[1:])
+	}
+
+	flags := flag.NewFlagSet("auth", flag.ExitOnError)
+	prodFlag := flags.Bool("prod", false, "Set the refresh token as a Fly.io secret")
+	noBrowserFlag := flags.Bool("no-browser", false, "Print the auth URL and read the redirect URL from stdin")
+	pkceFlag := flags.Bool("pkce", false, "Authenticate with PKCE, without the client secret")
+	hostFlag := flags.String("redirect-host", "", "Callback host (SPOTIFY_REDIRECT_HOST, default localhost)")
+	portFlag := flags.String("redirect-port", "", "Callback port (SPOTIFY_REDIRECT_PORT, default 8888)")
+	pathFlag := flags.String("redirect-path", "", "Callback path (SPOTIFY_REDIRECT_PATH, default /callback)")
+	scopesFlag := flags.String("scopes", "", "Comma separated scopes or presets (SPOTIFY_AUTH_SCOPES, default every preset)")
+	profileFlag := flags.String("profile", "", "Credential profile, e.g. staging reads .env.staging (SPOTIFY_PROFILE)")
+	flags.Parse(args)
+
+	if err := setProfile(*profileFlag); err != nil {
+		return err
+	}
+
+	// Flags win over the environment, which auth.NewOptions reads
+	opts := authOptions(*noBrowserFlag, *pkceFlag)
+	if *hostFlag != "" {
+		opts = append(opts, auth.WithRedirectHost(*hostFlag))
+	}
+	if *portFlag != "" {
+		opts = append(opts, auth.WithRedirectPort(*portFlag))
+	}
+	if *pathFlag != "" {
+		opts = append(opts, auth.WithRedirectPath(*pathFlag))
+	}
+	if *scopesFlag != "" {
+		opts = append(opts, auth.WithScopes(client.ResolveScopes(*scopesFlag)...))
+	}
+
+	return runAuth(*prodFlag, opts...)
+}
+
+// authStatusCommand is `spotify auth status`, which refreshes the local token
+// and reports who it belongs to and which features it can serve
+func authStatusCommand(args []string) error {
+	flags := flag.NewFlagSet("auth status", flag.ExitOnError)
+	profileFlag := flags.String("profile", "", "Credential profile, e.g. staging reads .env.staging (SPOTIFY_PROFILE)")
+	flags.Parse(args)
+
+	if err := setProfile(*profileFlag); err != nil {
+		return err
+	}
+
+	// Like run mode, the credentials may only be in the environment
+	usingSecretsFile, err := loadEnv()
+	if err != nil && os.Getenv("SPOTIFY_CLIENT_ID") == "" {
+		return fmt.Errorf("error loading from %s: %w", envFile(), err)
+	}
+
+	features, err := enabledFeatures()
+	if err != nil {
+		return err
+	}
+
+	spotifyClient, err := newLocalClient(usingSecretsFile)
+	if err != nil {
+		return err
+	}
+	if !spotifyClient.HasToken() {
+		return errors.New("no refresh token, run 'spotify auth' to get one")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	status, err := spotifyClient.Status(ctx)
+	if err != nil {
+		if errors.Is(err, client.ErrUnauthorized) {
+			return fmt.Errorf("refresh token is invalid or expired, run 'spotify auth' to get a new one: %w", err)
+		}
+		return err
+	}
+
+	fmt.Printf("User:     %s (%s)\n", status.User.DisplayName, status.User.ID)
+	if status.Scopes != nil {
+		fmt.Printf("Scopes:   %s\n", strings.Join(status.Scopes, ", "))
+	} else {
+		fmt.Println("Scopes:   not reported by Spotify")
+	}
+	fmt.Printf("Expires:  %s (in %s)\n", status.Expiry.Local().Format(time.DateTime), time.Until(status.Expiry).Round(time.Second))
+
+	fmt.Println("Features:")
+	usable := true
+	for _, feature := range client.Features {
+		enabled := slices.Contains(features, feature)
+		missing := status.Missing(feature)
+
+		icon, details := "✅", []string{}
+		if enabled {
+			details = append(details, "enabled")
+		}
+		if len(missing) > 0 {
+			icon = "❌"
+			details = append(details, "missing "+strings.Join(missing, ", "))
+			if enabled {
+				usable = false
+			}
+		}
+
+		line := fmt.Sprintf("  %s %s", icon, feature)
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+		fmt.Println(line)
+	}
+
+	if !usable {
+		return errors.New("the token doesn't cover every enabled feature, run 'spotify auth' to grant the missing scopes")
+	}
+	return nil
+}
+
+func authOptions(noBrowser bool, pkce bool) []func(*auth.Options) {
+	var opts []func(*auth.Options)
+	if noBrowser {
+		opts = append(opts, auth.WithNoBrowser())
+	}
+	if pkce {
+		opts = append(opts, auth.WithPKCE())
+	}
+	return opts
+}
+
+// runAuth loads the credentials the same way the server does, runs the auth
+// flow and saves the new refresh token
+func runAuth(isProduction bool, opts ...func(*auth.Options)) error {
+	// Production credentials may only be in the environment
+	if _, err := loadEnv(); err != nil && !isProduction {
+		return fmt.Errorf("error loading from %s: %w", envFile(), err)
+	}
+
+	options := auth.NewOptions(opts...)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	token, err := auth.Login(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	// The flow is saved next to the token, a PKCE token can't be refreshed
+	// with the secret that may still be set
+	flow := client.AuthFlowAuthorizationCode
+	if options.UsesPKCE() {
+		flow = client.AuthFlowPKCE
+	}
+
+	// Anything run after this, like a deploy, picks up the new token
+	os.Setenv("SPOTIFY_REFRESH_TOKEN", token.RefreshToken)
+	os.Setenv("SPOTIFY_AUTH_FLOW", string(flow))
+
+	if isProduction {
+		if flow == client.AuthFlowPKCE && os.Getenv("SPOTIFY_TOKEN_STORE") == "" {
+			log.Println("Warning: Spotify rotates PKCE refresh tokens, deploy with SPOTIFY_TOKEN_STORE on a Fly volume or this token stops working after a restart")
+		}
+		values := map[string]string{
+			"SPOTIFY_REFRESH_TOKEN": token.RefreshToken,
+			"SPOTIFY_AUTH_FLOW":     string(flow),
+		}
+		if err := importFlySecrets(values); err != nil {
+			log.Printf("Failed to set Fly secrets: %v", err)
+			// Through stdin, so the token stays off the command line and out of the shell history
+			fmt.Printf("Please set them manually, run `fly %s`, paste these lines and press Ctrl-D:\n", strings.Join(flyArgs("secrets", "import"), " "))
+			fmt.Printf("SPOTIFY_REFRESH_TOKEN=%s\nSPOTIFY_AUTH_FLOW=%s\n", token.RefreshToken, flow)
+		} else {
+			fmt.Println("✅ Production secrets updated successfully!")
+		}
+		return nil
+	}
+
+	if err := saveToken(token, flow); err != nil {
+		log.Printf("Failed to save refresh token: %v", err)
+		fmt.Printf("Please manually add to %s: SPOTIFY_REFRESH_TOKEN=%s and SPOTIFY_AUTH_FLOW=%s\n", envFile(), token.RefreshToken, flow)
+	}
+	return nil
+}
+
+// saveToken writes a token to the encrypted secrets file when a key is
+// configured, along with the client credentials so the file is all the
+// server needs, and otherwise to the token store the server reads. The
+// flow it came from is saved with it, so the server knows how to refresh it
+func saveToken(token *oauth2.Token, flow client.AuthFlow) error {
+	key, encrypt, err := secrets.KeyFromEnv()
+	if err != nil {
+		return err
+	}
+
+	if encrypt {
+		values := map[string]string{
+			"SPOTIFY_CLIENT_ID":     os.Getenv("SPOTIFY_CLIENT_ID"),
+			"SPOTIFY_REFRESH_TOKEN": token.RefreshToken,
+			"SPOTIFY_AUTH_FLOW":     string(flow),
+		}
+		// PKCE tokens must be refreshed without the secret, so it's left out
+		if flow != client.AuthFlowPKCE {
+			values["SPOTIFY_CLIENT_SECRET"] = os.Getenv("SPOTIFY_CLIENT_SECRET")
+		}
+		if err := secrets.Update(secretsFile(), key, values); err != nil {
+			return err
+		}
+		fmt.Println("✅ Encrypted secrets file updated successfully!")
+		return nil
+	}
+
+	store, err := tokenStoreFromEnv("env:" + envFile())
+	if err != nil {
+		return err
+	}
+	if err := store.Save(token); err != nil {
+		return err
+	}
+	if err := secrets.UpdateEnvFile(envFile(), map[string]string{"SPOTIFY_AUTH_FLOW": string(flow)}); err != nil {
+		return err
+	}
+	fmt.Println("✅ Local token store updated successfully!")
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Bounds memory use when cache keys come from query parameters
+const maxCacheEntries = 1000
+
+type cacheEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// responseCache holds rendered JSON responses so bursts of visitors don't hit Spotify's rate limits
+type responseCache struct {
+	mutex   sync.Mutex
+	entries map[string]cacheEntry
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{entries: map[string]cacheEntry{}}
+}
+
+// get returns a copy of the data cached under key if it hasn't expired
+func (c *responseCache) get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+
+	result := make([]byte, len(entry.data))
+	copy(result, entry.data)
+	return result, true
+}
+
+func (c *responseCache) set(key string, data []byte, ttl time.Duration) {
+	cached := make([]byte, len(data))
+	copy(cached, data)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxCacheEntries {
+		c.evict()
+	}
+	c.entries[key] = cacheEntry{data: cached, expires: time.Now().Add(ttl)}
+}
+
+// evict drops expired entries, or the one closest to expiring if none have
+func (c *responseCache) evict() {
+	now := time.Now()
+	var soonest string
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		} else if soonest == "" || entry.expires.Before(c.entries[soonest].expires) {
+			soonest = key
+		}
+	}
+
+	if len(c.entries) >= maxCacheEntries {
+		delete(c.entries, soonest)
+	}
+}
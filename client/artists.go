@@ -0,0 +1,163 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Followed artists need the user-follow-read scope
+	followingPath = "/me/following"
+	artistPath    = "/artists/"
+	artistsPath   = "/artists"
+)
+
+// Spotify accepts at most 50 IDs per request to /artists
+const maxArtistIDs = 50
+
+func followedArtistsParams(options *RequestOptions) url.Values {
+	params := url.Values{
+		"type":  {"artist"},
+		"limit": {strconv.Itoa(options.Limit)},
+	}
+	if options.Cursor != "" {
+		params.Set("after", options.Cursor)
+	}
+	return params
+}
+
+func artistAlbumsParams(options *RequestOptions) url.Values {
+	params := options.offsetParams()
+	if len(options.AlbumGroups) > 0 {
+		params.Set("include_groups", strings.Join(options.AlbumGroups, ","))
+	}
+	if options.Market != "" {
+		params.Set("market", options.Market)
+	}
+	return params
+}
+
+// GetFollowedArtists returns a page of followed artists, pass the returned Cursor
+// to Cursor for the next one
+func (s *SpotifyClient) GetFollowedArtists(ctx context.Context, opts ...func(*RequestOptions)) (*FollowedArtists, error) {
+	fa := &SpotifyFollowedArtists{}
+	options := s.requestOptions(opts)
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(followingPath), followedArtistsParams(options), nil, fa)
+	if err != nil {
+		return nil, err
+	}
+	return fa.Convert(), nil
+}
+
+func (s *SpotifyClient) GetArtist(ctx context.Context, artistID string) (*Artist, error) {
+	a := &SpotifyArtist{}
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(artistPath+url.PathEscape(artistID)), nil, nil, a)
+	if err != nil {
+		return nil, err
+	}
+	return a.convert(), nil
+}
+
+// GetArtists looks up any number of artists, batching the requests. The result
+// lines up with artistIDs, unknown IDs are nil
+func (s *SpotifyClient) GetArtists(ctx context.Context, artistIDs ...string) ([]*Artist, error) {
+	artists := make([]*Artist, 0, len(artistIDs))
+
+	for chunk := range slices.Chunk(artistIDs, maxArtistIDs) {
+		a := &SpotifyArtists{}
+		params := url.Values{
+			"ids": {strings.Join(chunk, ",")},
+		}
+
+		err := s.doRequest(ctx, http.MethodGet, s.endpoint(artistsPath), params, nil, a)
+		if err != nil {
+			return nil, err
+		}
+
+		// Keep the result aligned with the IDs even if Spotify returns fewer
+		converted := make([]*Artist, len(chunk))
+		for i, artist := range a.Artists[:min(len(a.Artists), len(chunk))] {
+			converted[i] = artist.convert()
+		}
+		artists = append(artists, converted...)
+	}
+
+	return artists, nil
+}
+
+// GetArtistTopTracks returns up to 10 of an artist's most popular tracks in a market,
+// which defaults to the user's country
+func (s *SpotifyClient) GetArtistTopTracks(ctx context.Context, artistID string, opts ...func(*RequestOptions)) (*TopTracks, error) {
+	tt := &SpotifyArtistTopTracks{}
+	options := s.requestOptions(opts)
+
+	params := url.Values{
+		"market": {"from_token"},
+	}
+	if options.Market != "" {
+		params.Set("market", options.Market)
+	}
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(artistPath+url.PathEscape(artistID)+"/top-tracks"), params, nil, tt)
+	if err != nil {
+		return nil, err
+	}
+	return &TopTracks{Tracks: convertTracks(tt.Tracks)}, nil
+}
+
+func (s *SpotifyClient) GetArtistAlbums(ctx context.Context, artistID string, opts ...func(*RequestOptions)) (*Albums, error) {
+	a := &SpotifyAlbums{}
+	options := s.requestOptions(opts)
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(artistPath+url.PathEscape(artistID)+"/albums"), artistAlbumsParams(options), nil, a)
+	if err != nil {
+		return nil, err
+	}
+	return a.Convert(), nil
+}
+
+// FillArtistDetails looks up the genres, images and followers of every artist on
+// the tracks, which Spotify leaves out of the artists embedded in a track
+func (s *SpotifyClient) FillArtistDetails(ctx context.Context, tracks ...*Track) error {
+	byID := map[string][]*Artist{}
+	var ids []string
+	for _, track := range tracks {
+		if track == nil {
+			continue
+		}
+		for _, artist := range track.Artists {
+			if artist == nil || artist.ID == "" {
+				continue
+			}
+			if _, seen := byID[artist.ID]; !seen {
+				ids = append(ids, artist.ID)
+			}
+			byID[artist.ID] = append(byID[artist.ID], artist)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	details, err := s.GetArtists(ctx, ids...)
+	if err != nil {
+		return err
+	}
+
+	for i, detail := range details {
+		if detail == nil {
+			continue
+		}
+		for _, artist := range byID[ids[i]] {
+			*artist = *detail
+		}
+	}
+	return nil
+}
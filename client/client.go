@@ -1,13 +1,22 @@
 package client
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -15,16 +24,24 @@ import (
 )
 
 const (
-	spotifyBaseURL    = "https://api.spotify.com/v1"
-	currentlyPlaying  = spotifyBaseURL + "/me/player/currently-playing"
-	recentlyPlayedURL = spotifyBaseURL + "/me/player/recently-played"
-	topTracksURL      = spotifyBaseURL + "/me/top/tracks"
-	topArtistsURL     = spotifyBaseURL + "/me/top/artists"
+	spotifyBaseURL       = "https://api.spotify.com/v1"
+	currentUserPath      = "/me"
+	playerPath           = "/me/player"
+	currentlyPlayingPath = "/me/player/currently-playing"
+	recentlyPlayedPath   = "/me/player/recently-played"
+	topTracksPath        = "/me/top/tracks"
+	topArtistsPath       = "/me/top/artists"
 )
 
 type SpotifyClient struct {
-	client  *http.Client
-	options *Options
+	client      *http.Client
+	oauthConfig *oauth2.Config
+	tokenSource *tokenSwitch
+	options     *Options
+	retries     atomic.Int64
+
+	contextNamesMutex sync.Mutex
+	contextNames      map[string]cachedContextName
 }
 
 type Options struct {
@@ -33,8 +50,30 @@ type Options struct {
 	RefreshToken string
 	Limit        string
 	TimeRange    TimeRange
+	// BaseURL is the root of the Web API, e.g. an httptest server standing in for Spotify
+	BaseURL string
+	// TokenURL overrides the OAuth2 token endpoint used to refresh access tokens
+	TokenURL string
+	// HTTPClient is used for both token refreshes and API calls
+	HTTPClient  *http.Client
+	RetryPolicy RetryPolicy
+	// TokenStore loads the refresh token at startup and saves it whenever Spotify rotates it
+	TokenStore TokenStore
+	// Transport replaces the round tripper of HTTPClient (or http.DefaultTransport),
+	// wrap http.DefaultTransport to add middleware
+	Transport http.RoundTripper
+	// AuthFlow is the flow the refresh token came from, ClientSecret is ignored for AuthFlowPKCE
+	AuthFlow AuthFlow
 }
 
+// AuthFlow is the OAuth2 flow a refresh token was issued by, which decides how it's refreshed
+type AuthFlow string
+
+const (
+	AuthFlowAuthorizationCode AuthFlow = "authorization-code"
+	AuthFlowPKCE              AuthFlow = "pkce"
+)
+
 type TimeRange string
 
 const (
@@ -64,12 +103,7 @@ func WithRefreshToken(refreshToken string) func(*Options) {
 
 func WithLimit(limit int) func(*Options) {
 	return func(o *Options) {
-		if limit < 1 {
-			limit = 1
-		} else if limit > 50 {
-			limit = 50
-		}
-		o.Limit = fmt.Sprintf("%d", limit)
+		o.Limit = strconv.Itoa(clampLimit(limit))
 	}
 }
 
@@ -79,6 +113,36 @@ func WithTimeRange(timeRange TimeRange) func(*Options) {
 	}
 }
 
+func WithBaseURL(baseURL string) func(*Options) {
+	return func(o *Options) {
+		o.BaseURL = baseURL
+	}
+}
+
+func WithTokenURL(tokenURL string) func(*Options) {
+	return func(o *Options) {
+		o.TokenURL = tokenURL
+	}
+}
+
+func WithHTTPClient(httpClient *http.Client) func(*Options) {
+	return func(o *Options) {
+		o.HTTPClient = httpClient
+	}
+}
+
+func WithTransport(transport http.RoundTripper) func(*Options) {
+	return func(o *Options) {
+		o.Transport = transport
+	}
+}
+
+func WithAuthFlow(flow AuthFlow) func(*Options) {
+	return func(o *Options) {
+		o.AuthFlow = flow
+	}
+}
+
 func NewSpotifyClient(opts ...func(*Options)) *SpotifyClient {
 	options := &Options{
 		Limit:        "5",
@@ -86,39 +150,108 @@ func NewSpotifyClient(opts ...func(*Options)) *SpotifyClient {
 		ClientID:     os.Getenv("SPOTIFY_CLIENT_ID"),
 		ClientSecret: os.Getenv("SPOTIFY_CLIENT_SECRET"),
 		RefreshToken: os.Getenv("SPOTIFY_REFRESH_TOKEN"),
+		BaseURL:      cmp.Or(os.Getenv("SPOTIFY_API_URL"), spotifyBaseURL),
+		TokenURL:     os.Getenv("SPOTIFY_TOKEN_URL"),
+		RetryPolicy:  DefaultRetryPolicy,
+		AuthFlow:     AuthFlow(os.Getenv("SPOTIFY_AUTH_FLOW")),
 	}
 
 	for _, opt := range opts {
 		opt(options)
 	}
+	options.BaseURL = strings.TrimRight(options.BaseURL, "/")
+	// Spotify rejects a PKCE token refreshed with the secret, which may still be in .env
+	if options.AuthFlow == AuthFlowPKCE {
+		options.ClientSecret = ""
+	}
+
+	endpoint := spotify.Endpoint
+	if options.TokenURL != "" {
+		endpoint.TokenURL = options.TokenURL
+	}
+	// Tokens from the PKCE flow are refreshed by public clients, which only send their ID
+	if options.ClientSecret == "" {
+		endpoint.AuthStyle = oauth2.AuthStyleInParams
+	}
 
 	cfg := &oauth2.Config{
 		ClientID:     options.ClientID,
 		ClientSecret: options.ClientSecret,
-		Endpoint:     spotify.Endpoint,
+		Endpoint:     endpoint,
 	}
 
 	token := &oauth2.Token{
 		RefreshToken: options.RefreshToken,
 	}
 
-	ctx := context.Background()
-	client := cfg.Client(ctx, token)
-	client.Timeout = 10 * time.Second
+	// A stored token wins over the environment, it may have been rotated since
+	if options.TokenStore != nil {
+		stored, err := options.TokenStore.Load()
+		if err == nil && stored.RefreshToken != "" {
+			token = stored
+		} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("Error loading stored token, falling back to SPOTIFY_REFRESH_TOKEN: %v", err)
+		}
+	}
+
+	spotifyClient := &SpotifyClient{
+		oauthConfig:  cfg,
+		tokenSource:  &tokenSwitch{},
+		options:      options,
+		contextNames: map[string]cachedContextName{},
+	}
 
-	return &SpotifyClient{
-		client:  client,
-		options: options,
+	// Without a refresh token requests fail with ErrUnauthorized until SetToken is called
+	if token.RefreshToken != "" {
+		spotifyClient.tokenSource.set(spotifyClient.newTokenSource(token))
 	}
+
+	var base http.RoundTripper
+	if client := options.baseHTTPClient(); client != nil {
+		base = client.Transport
+	}
+
+	// The switch is read on every request, so a token set later is used straight away
+	spotifyClient.client = &http.Client{
+		Transport: &oauth2.Transport{Source: spotifyClient.tokenSource, Base: base},
+		Timeout:   10 * time.Second,
+	}
+	if options.HTTPClient != nil && options.HTTPClient.Timeout != 0 {
+		spotifyClient.client.Timeout = options.HTTPClient.Timeout
+	}
+
+	return spotifyClient
+}
+
+func (o *Options) baseHTTPClient() *http.Client {
+	if o.HTTPClient == nil && o.Transport == nil {
+		return nil
+	}
+
+	base := &http.Client{}
+	if o.HTTPClient != nil {
+		clone := *o.HTTPClient
+		base = &clone
+	}
+	if o.Transport != nil {
+		base.Transport = o.Transport
+	}
+	return base
+}
+
+func (s *SpotifyClient) endpoint(path string) string {
+	return s.options.BaseURL + path
+}
+
+// Podcast episodes are only returned when asked for
+var additionalTypes = url.Values{
+	"additional_types": {"track,episode"},
 }
 
 func (s *SpotifyClient) GetCurrentlyPlaying(ctx context.Context) (*CurrentlyPlaying, error) {
 	cp := &SpotifyCurrentlyPlaying{}
-	params := url.Values{
-		"limit": {s.options.Limit},
-	}
 
-	err := s.doRequest(ctx, currentlyPlaying, params, cp)
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(currentlyPlayingPath), additionalTypes, nil, cp)
 	if err != nil {
 		return nil, err
 	}
@@ -130,62 +263,153 @@ func (s *SpotifyClient) GetCurrentlyPlaying(ctx context.Context) (*CurrentlyPlay
 	return cp.Convert(), nil
 }
 
-func (s *SpotifyClient) GetRecentlyPlayed(ctx context.Context) (*RecentlyPlayedTracks, error) {
-	rp := &SpotifyRecentlyPlayedTracks{}
+// GetPlaybackState returns nil when there's no active device
+func (s *SpotifyClient) GetPlaybackState(ctx context.Context) (*PlaybackState, error) {
+	ps := &SpotifyPlaybackState{}
 
-	params := url.Values{
-		"limit": {s.options.Limit},
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(playerPath), additionalTypes, nil, ps)
+	if err != nil {
+		return nil, err
 	}
 
-	err := s.doRequest(ctx, recentlyPlayedURL, params, rp)
+	if ps.Device == nil {
+		return nil, nil
+	}
+
+	return ps.Convert(), nil
+}
+
+// GetCurrentUser returns the profile of the user the refresh token belongs to
+func (s *SpotifyClient) GetCurrentUser(ctx context.Context) (*User, error) {
+	u := &SpotifyUser{}
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(currentUserPath), nil, nil, u)
 	if err != nil {
 		return nil, err
 	}
-	return rp.Convert(), nil
+	return u.convert(), nil
 }
 
-func (s *SpotifyClient) GetTopArtists(ctx context.Context) (*TopArtists, error) {
-	ta := &SpotifyTopArtists{}
+// GetQueue returns the item that's playing and what's up next
+func (s *SpotifyClient) GetQueue(ctx context.Context) (*Queue, error) {
+	q := &SpotifyQueue{}
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(queuePath), nil, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return q.Convert(), nil
+}
+
+func (s *SpotifyClient) GetRecentlyPlayed(ctx context.Context, opts ...func(*RequestOptions)) (*RecentlyPlayedTracks, error) {
+	rp := &SpotifyRecentlyPlayedTracks{}
+	options := s.requestOptions(opts)
 
-	params := url.Values{
-		"limit":      {s.options.Limit},
-		TimeRangeTag: {string(s.options.TimeRange)},
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(recentlyPlayedPath), options.cursorParams(), nil, rp)
+	if err != nil {
+		return nil, err
 	}
 
-	err := s.doRequest(ctx, topArtistsURL, params, ta)
+	recentlyPlayed := rp.Convert()
+	if recentlyPlayed != nil {
+		s.resolveContextNames(ctx, recentlyPlayed.RecentlyPlayed)
+	}
+	return recentlyPlayed, nil
+}
+
+func (s *SpotifyClient) GetTopArtists(ctx context.Context, opts ...func(*RequestOptions)) (*TopArtists, error) {
+	ta := &SpotifyTopArtists{}
+	options := s.requestOptions(opts)
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(topArtistsPath), options.topParams(), nil, ta)
 	if err != nil {
 		return nil, err
 	}
 	return ta.Convert(), nil
 }
 
-func (s *SpotifyClient) GetTopTracks(ctx context.Context) (*TopTracks, error) {
+func (s *SpotifyClient) GetTopTracks(ctx context.Context, opts ...func(*RequestOptions)) (*TopTracks, error) {
 	tt := &SpotifyTopTracks{}
+	options := s.requestOptions(opts)
 
-	params := url.Values{
-		"limit":      {s.options.Limit},
-		TimeRangeTag: {string(s.options.TimeRange)},
-	}
-
-	err := s.doRequest(ctx, topTracksURL, params, tt)
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(topTracksPath), options.topParams(), nil, tt)
 	if err != nil {
 		return nil, err
 	}
 	return tt.Convert(), nil
 }
 
-func (s *SpotifyClient) doRequest(ctx context.Context, url string, params url.Values, result interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+// doRequest sends body as JSON when it's non-nil and decodes the response into
+// result when it's non-nil
+func (s *SpotifyClient) doRequest(ctx context.Context, method string, url string, params url.Values, body interface{}, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+	}
+
+	// Retrying a failed skip or queue could apply it twice
+	idempotent := method != http.MethodPost
+
+	policy := &s.options.RetryPolicy
+	for attempt := 0; ; attempt++ {
+		err := s.doRequestOnce(ctx, method, url, params, payload, result)
+		if err == nil {
+			return nil
+		}
+
+		wait, ok := policy.retryDelay(attempt, idempotent, err)
+		if !ok {
+			return err
+		}
+		if deadline, hasDeadline := ctx.Deadline(); hasDeadline && time.Until(deadline) < wait {
+			return err
+		}
+
+		s.retries.Add(1)
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt+1, wait, err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *SpotifyClient) doRequestOnce(ctx context.Context, method string, url string, params url.Values, payload []byte, result interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
 	if params != nil {
 		req.URL.RawQuery = params.Encode()
 	}
 
 	r, err := s.client.Do(req)
 	if err != nil {
+		// The refresh token was rejected by the token endpoint, anything
+		// else is a url.Error which is retried
+		if isTokenRejected(err) {
+			return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer r.Body.Close()
@@ -194,20 +418,13 @@ func (s *SpotifyClient) doRequest(ctx context.Context, url string, params url.Va
 		return nil
 	}
 
-	if r.StatusCode == http.StatusUnauthorized {
-		return fmt.Errorf("unauthorized: invalid or expired token")
-	}
-
-	if r.StatusCode == http.StatusTooManyRequests {
-		return fmt.Errorf("rate limited by Spotify API")
+	if r.StatusCode != http.StatusOK && r.StatusCode != http.StatusAccepted {
+		return newAPIError(r)
 	}
 
-	if r.StatusCode >= 500 {
-		return fmt.Errorf("spotify server error: %d", r.StatusCode)
-	}
-
-	if r.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", r.StatusCode)
+	// Player commands reply with an empty body or one we don't care about
+	if result == nil {
+		return nil
 	}
 
 	// Limit response body size to prevent memory exhaustion
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testRetryPolicy keeps tests that hit retries fast
+var testRetryPolicy = RetryPolicy{
+	MaxRetries: 2,
+	BaseDelay:  time.Millisecond,
+	MaxDelay:   10 * time.Millisecond,
+}
+
+// newTestClient points a client at api, with a token endpoint answering tokenStatus
+func newTestClient(t *testing.T, api http.Handler, tokenStatus int, opts ...func(*Options)) *SpotifyClient {
+	t.Helper()
+
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(tokenStatus)
+		switch tokenStatus {
+		case http.StatusOK:
+			fmt.Fprint(w, `{"access_token": "access", "token_type": "Bearer", "expires_in": 3600}`)
+		case http.StatusBadRequest:
+			fmt.Fprint(w, `{"error": "invalid_grant", "error_description": "Invalid refresh token"}`)
+		}
+	}))
+	t.Cleanup(tokens.Close)
+
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	opts = append([]func(*Options){
+		WithClientID("id"),
+		WithClientSecret("secret"),
+		WithRefreshToken("refresh"),
+		WithBaseURL(server.URL),
+		WithTokenURL(tokens.URL),
+		WithRetryPolicy(testRetryPolicy),
+	}, opts...)
+	return NewSpotifyClient(opts...)
+}
+
+func TestBaseURLAndTokenURL(t *testing.T) {
+	var refreshToken string
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		refreshToken = r.PostForm.Get("refresh_token")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token": "access", "token_type": "Bearer", "expires_in": 3600}`)
+	}))
+	defer tokens.Close()
+
+	var path, authorization string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, authorization = r.URL.Path, r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"id": "ash", "display_name": "Ash"}`)
+	}))
+	defer api.Close()
+
+	spotifyClient := NewSpotifyClient(
+		WithClientID("id"),
+		WithClientSecret("secret"),
+		WithRefreshToken("refresh"),
+		// A trailing slash doesn't double up with the endpoint paths
+		WithBaseURL(api.URL+"/v1/"),
+		WithTokenURL(tokens.URL),
+	)
+
+	user, err := spotifyClient.GetCurrentUser(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != "ash" {
+		t.Errorf("user = %q, want %q", user.ID, "ash")
+	}
+	if path != "/v1/me" {
+		t.Errorf("path = %q, want %q", path, "/v1/me")
+	}
+	if refreshToken != "refresh" {
+		t.Errorf("refreshed with %q, want %q", refreshToken, "refresh")
+	}
+	if authorization != "Bearer access" {
+		t.Errorf("Authorization = %q, want %q", authorization, "Bearer access")
+	}
+}
+
+// recordingTransport records the hosts it's asked to reach
+type recordingTransport struct {
+	mutex sync.Mutex
+	hosts []string
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mutex.Lock()
+	r.hosts = append(r.hosts, req.URL.Host)
+	r.mutex.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name string
+		// opts returns the options under test, and the transport that must see every request
+		opts func() ([]func(*Options), *recordingTransport)
+	}{
+		{"transport", func() ([]func(*Options), *recordingTransport) {
+			transport := &recordingTransport{}
+			return []func(*Options){WithTransport(transport)}, transport
+		}},
+		{"http client", func() ([]func(*Options), *recordingTransport) {
+			transport := &recordingTransport{}
+			return []func(*Options){WithHTTPClient(&http.Client{Transport: transport})}, transport
+		}},
+		{"transport wins over the http client's", func() ([]func(*Options), *recordingTransport) {
+			transport := &recordingTransport{}
+			return []func(*Options){
+				WithHTTPClient(&http.Client{Transport: &recordingTransport{}}),
+				WithTransport(transport),
+			}, transport
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, transport := tt.opts()
+
+			api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"id": "ash"}`)
+			})
+			spotifyClient := newTestClient(t, api, http.StatusOK, opts...)
+			if _, err := spotifyClient.GetCurrentUser(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			// The token refresh and the API call both go through the transport
+			hosts := slices.Compact(transport.hosts)
+			if len(hosts) != 2 {
+				t.Errorf("transport saw %v, want the token endpoint then the API", transport.hosts)
+			}
+		})
+	}
+}
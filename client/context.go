@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// contextNameTTL lets a renamed playlist show its new name
+	contextNameTTL = time.Hour
+	// maxContextNames caps the cache, the least recently fetched names go first
+	maxContextNames = 500
+	// contextLookupTimeout bounds each lookup, names aren't worth holding up a response
+	contextLookupTimeout = 3 * time.Second
+)
+
+type cachedContextName struct {
+	name    string
+	expires time.Time
+}
+
+// Endpoints that can name a playback context, keyed by the context type
+var contextPaths = map[string]string{
+	"playlist": playlistPath,
+	"album":    "/albums/",
+	"artist":   artistPath,
+	"show":     "/shows/",
+}
+
+// resolveContextNames fills in the names of the playlists, albums, artists and
+// shows that tracks were played from. Names are cached for contextNameTTL, a
+// context that can't be looked up (e.g. a private playlist) is left unnamed.
+// Lookups are skipped when ctx doesn't leave time for them
+func (s *SpotifyClient) resolveContextNames(ctx context.Context, items []*RecentlyPlayed) {
+	pending := map[string][]*PlaybackContext{}
+	now := time.Now()
+
+	s.contextNamesMutex.Lock()
+	for _, item := range items {
+		if item == nil || item.Context == nil {
+			continue
+		}
+		if cached, ok := s.contextNames[item.Context.URI]; ok && now.Before(cached.expires) {
+			item.Context.Name = cached.name
+			continue
+		}
+		pending[item.Context.URI] = append(pending[item.Context.URI], item.Context)
+	}
+	s.contextNamesMutex.Unlock()
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < contextLookupTimeout {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for uri, contexts := range pending {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			lookupCtx, cancel := context.WithTimeout(ctx, contextLookupTimeout)
+			defer cancel()
+
+			name, err := s.getContextName(lookupCtx, contexts[0].Type, uri)
+			// Only remember contexts Spotify won't show us, anything else may work next time
+			if err != nil && !errors.Is(err, ErrForbidden) && !isNotFound(err) {
+				return
+			}
+			s.cacheContextName(uri, name)
+
+			for _, c := range contexts {
+				c.Name = name
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *SpotifyClient) cacheContextName(uri string, name string) {
+	s.contextNamesMutex.Lock()
+	defer s.contextNamesMutex.Unlock()
+
+	now := time.Now()
+	if len(s.contextNames) >= maxContextNames {
+		for key, cached := range s.contextNames {
+			if !now.Before(cached.expires) {
+				delete(s.contextNames, key)
+			}
+		}
+	}
+	// Every name has the same TTL, so the one expiring first is the oldest
+	for len(s.contextNames) >= maxContextNames {
+		oldest := ""
+		for key, cached := range s.contextNames {
+			if oldest == "" || cached.expires.Before(s.contextNames[oldest].expires) {
+				oldest = key
+			}
+		}
+		delete(s.contextNames, oldest)
+	}
+
+	s.contextNames[uri] = cachedContextName{name: name, expires: now.Add(contextNameTTL)}
+}
+
+func (s *SpotifyClient) getContextName(ctx context.Context, contextType string, uri string) (string, error) {
+	if contextType == "collection" {
+		return "Liked Songs", nil
+	}
+
+	path, ok := contextPaths[contextType]
+	if !ok {
+		return "", nil
+	}
+
+	// URIs look like spotify:playlist:{id}
+	id := uri[strings.LastIndex(uri, ":")+1:]
+	if id == "" {
+		return "", nil
+	}
+
+	var params url.Values
+	if contextType == "playlist" {
+		params = url.Values{"fields": {"name"}}
+	}
+
+	result := &struct {
+		Name string `json:"name"`
+	}{}
+	if err := s.doRequest(ctx, http.MethodGet, s.endpoint(path+url.PathEscape(id)), params, nil, result); err != nil {
+		return "", err
+	}
+	return result.Name, nil
+}
+
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func playedFrom(uris ...string) []*RecentlyPlayed {
+	items := make([]*RecentlyPlayed, len(uris))
+	for i, uri := range uris {
+		items[i] = &RecentlyPlayed{Context: &PlaybackContext{Type: strings.Split(uri, ":")[1], URI: uri}}
+	}
+	return items
+}
+
+func TestResolveContextNames(t *testing.T) {
+	tests := []struct {
+		name string
+		// cached is set up before resolving, keyed by URI
+		cached      map[string]cachedContextName
+		timeout     time.Duration
+		uris        []string
+		wantNames   []string
+		wantLookups int64
+	}{
+		{
+			"looks up each context once",
+			nil, 0,
+			[]string{"spotify:playlist:a", "spotify:playlist:a", "spotify:album:b"},
+			[]string{"playlist a", "playlist a", "album b"},
+			2,
+		},
+		{
+			"liked songs aren't looked up",
+			nil, 0,
+			[]string{"spotify:collection:ash"},
+			[]string{"Liked Songs"},
+			0,
+		},
+		{
+			"uses cached names",
+			map[string]cachedContextName{"spotify:playlist:a": {"Cached", time.Now().Add(time.Minute)}}, 0,
+			[]string{"spotify:playlist:a"},
+			[]string{"Cached"},
+			0,
+		},
+		{
+			"refetches expired names",
+			map[string]cachedContextName{"spotify:playlist:a": {"Old name", time.Now().Add(-time.Second)}}, 0,
+			[]string{"spotify:playlist:a"},
+			[]string{"playlist a"},
+			1,
+		},
+		{
+			"skips lookups close to the deadline",
+			map[string]cachedContextName{"spotify:playlist:a": {"Cached", time.Now().Add(time.Minute)}}, time.Second,
+			[]string{"spotify:playlist:a", "spotify:album:b"},
+			[]string{"Cached", ""},
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lookups atomic.Int64
+			api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				lookups.Add(1)
+				parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+				fmt.Fprintf(w, `{"name": "%s %s"}`, strings.TrimSuffix(parts[0], "s"), parts[1])
+			})
+			spotifyClient := newTestClient(t, api, http.StatusOK)
+			for uri, cached := range tt.cached {
+				spotifyClient.contextNames[uri] = cached
+			}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			items := playedFrom(tt.uris...)
+			spotifyClient.resolveContextNames(ctx, items)
+
+			for i, item := range items {
+				if item.Context.Name != tt.wantNames[i] {
+					t.Errorf("%s name = %q, want %q", item.Context.URI, item.Context.Name, tt.wantNames[i])
+				}
+			}
+			if got := lookups.Load(); got != tt.wantLookups {
+				t.Errorf("lookups = %d, want %d", got, tt.wantLookups)
+			}
+		})
+	}
+}
+
+func TestContextNameCacheCap(t *testing.T) {
+	spotifyClient := NewSpotifyClient(WithClientID("id"))
+
+	for i := range maxContextNames + 10 {
+		spotifyClient.cacheContextName(fmt.Sprintf("spotify:playlist:%d", i), "name")
+	}
+
+	if got := len(spotifyClient.contextNames); got != maxContextNames {
+		t.Errorf("cached %d names, want %d", got, maxContextNames)
+	}
+	// The newest name always makes it in
+	last := fmt.Sprintf("spotify:playlist:%d", maxContextNames+9)
+	if _, ok := spotifyClient.contextNames[last]; !ok {
+		t.Errorf("%s was evicted", last)
+	}
+}
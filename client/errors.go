@@ -0,0 +1,159 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+var (
+	// ErrUnauthorized is returned when the access token is invalid or expired,
+	// or when the refresh token can no longer be exchanged for a new one
+	ErrUnauthorized = errors.New("unauthorized: invalid or expired token")
+	// ErrForbidden is returned when the token is valid but lacks a required scope
+	ErrForbidden = errors.New("forbidden: token is missing a required scope")
+)
+
+// APIError is a non-successful response from the Spotify Web API
+type APIError struct {
+	StatusCode int
+	Message    string
+	// Reason is set by the player endpoints, e.g. PREMIUM_REQUIRED or NO_ACTIVE_DEVICE
+	Reason string
+	// Scope is the scope the endpoint needs, set on 403 responses when known
+	Scope string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("spotify api error: %d", e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Reason != "" {
+		msg += " (" + e.Reason + ")"
+	}
+	if e.Scope != "" {
+		msg += fmt.Sprintf(" (requires scope %q)", e.Scope)
+	}
+	return msg
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+// RateLimitError is returned when Spotify responds with 429 Too Many Requests
+type RateLimitError struct {
+	*APIError
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited by Spotify API, retry after %s", e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return e.APIError
+}
+
+// isTokenRejected reports whether the token endpoint rejected the refresh
+// token. Other failures, like a 5xx from the token endpoint, are transient
+func isTokenRejected(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if !errors.As(err, &retrieveErr) {
+		return false
+	}
+	if retrieveErr.ErrorCode == "invalid_grant" {
+		return true
+	}
+	if retrieveErr.Response == nil {
+		return false
+	}
+	status := retrieveErr.Response.StatusCode
+	return status == http.StatusBadRequest || status == http.StatusUnauthorized
+}
+
+// Spotify wraps every Web API error in {"error": {"status": ..., "message": ...}}
+type spotifyErrorBody struct {
+	Error struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+		Reason  string `json:"reason"`
+	} `json:"error"`
+}
+
+// Scopes required by the endpoints, used to explain 403 responses
+var endpointScopes = map[string]string{
+	currentUserPath:      "user-read-private",
+	playerPath:           "user-read-playback-state",
+	queuePath:            "user-read-playback-state",
+	currentlyPlayingPath: "user-read-currently-playing",
+	recentlyPlayedPath:   "user-read-recently-played",
+	topTracksPath:        "user-top-read",
+	topArtistsPath:       "user-top-read",
+	savedTracksPath:      "user-library-read",
+	savedAlbumsPath:      "user-library-read",
+	savedShowsPath:       "user-library-read",
+	playlistsPath:        "playlist-read-private",
+	followingPath:        "user-follow-read",
+}
+
+func newAPIError(r *http.Response) error {
+	apiErr := &APIError{StatusCode: r.StatusCode}
+
+	const maxErrorSize = 64 << 10 // 64KB
+	var body spotifyErrorBody
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxErrorSize)).Decode(&body); err == nil {
+		apiErr.Message = body.Error.Message
+		apiErr.Reason = body.Error.Reason
+	}
+
+	if r.StatusCode == http.StatusForbidden && r.Request != nil {
+		apiErr.Scope = requiredScope(r.Request)
+	}
+
+	if r.StatusCode == http.StatusTooManyRequests {
+		return &RateLimitError{
+			APIError:   apiErr,
+			RetryAfter: parseRetryAfter(r.Header.Get("Retry-After")),
+		}
+	}
+
+	return apiErr
+}
+
+func requiredScope(r *http.Request) string {
+	// Every player command changes playback
+	if r.Method != http.MethodGet && strings.Contains(r.URL.Path, playerPath) {
+		return "user-modify-playback-state"
+	}
+
+	for path, scope := range endpointScopes {
+		if strings.HasSuffix(r.URL.Path, path) {
+			return scope
+		}
+	}
+	return ""
+}
+
+// Spotify sends Retry-After in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		status     int
+		retryAfter string
+		body       string
+		want       APIError
+		wantRetry  time.Duration
+		wantIs     error
+	}{
+		{
+			"player reason", http.MethodPut, "/v1/me/player/pause", 403, "",
+			`{"error": {"status": 403, "message": "Player command failed: Premium required", "reason": "PREMIUM_REQUIRED"}}`,
+			APIError{StatusCode: 403, Message: "Player command failed: Premium required", Reason: "PREMIUM_REQUIRED", Scope: "user-modify-playback-state"},
+			0, ErrForbidden,
+		},
+		{
+			"no active device", http.MethodPost, "/v1/me/player/next", 404, "",
+			`{"error": {"status": 404, "message": "Player command failed: No active device found", "reason": "NO_ACTIVE_DEVICE"}}`,
+			APIError{StatusCode: 404, Message: "Player command failed: No active device found", Reason: "NO_ACTIVE_DEVICE"},
+			0, nil,
+		},
+		{
+			"missing scope", http.MethodGet, "/v1/me/tracks", 403, "",
+			`{"error": {"status": 403, "message": "Insufficient client scope"}}`,
+			APIError{StatusCode: 403, Message: "Insufficient client scope", Scope: "user-library-read"},
+			0, ErrForbidden,
+		},
+		{
+			"expired token", http.MethodGet, "/v1/me", 401, "",
+			`{"error": {"status": 401, "message": "The access token expired"}}`,
+			APIError{StatusCode: 401, Message: "The access token expired"},
+			0, ErrUnauthorized,
+		},
+		{
+			"rate limited", http.MethodGet, "/v1/me", 429, "3",
+			`{"error": {"status": 429, "message": "API rate limit exceeded"}}`,
+			APIError{StatusCode: 429, Message: "API rate limit exceeded"},
+			3 * time.Second, nil,
+		},
+		{
+			"not json", http.MethodGet, "/v1/me", 502, "",
+			`<html>Bad Gateway</html>`,
+			APIError{StatusCode: 502},
+			0, nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+				Request:    &http.Request{Method: tt.method, URL: &url.URL{Path: tt.path}},
+			}
+			if tt.retryAfter != "" {
+				r.Header.Set("Retry-After", tt.retryAfter)
+			}
+
+			err := newAPIError(r)
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want an APIError", err)
+			}
+			if *apiErr != tt.want {
+				t.Errorf("APIError = %+v, want %+v", *apiErr, tt.want)
+			}
+			if tt.wantIs != nil && !errors.Is(err, tt.wantIs) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.wantIs)
+			}
+
+			var rateLimitErr *RateLimitError
+			if isRateLimit := errors.As(err, &rateLimitErr); isRateLimit != (tt.status == http.StatusTooManyRequests) {
+				t.Fatalf("rate limit error = %v, want %v", isRateLimit, tt.status == http.StatusTooManyRequests)
+			}
+			if rateLimitErr != nil && rateLimitErr.RetryAfter != tt.wantRetry {
+				t.Errorf("RetryAfter = %s, want %s", rateLimitErr.RetryAfter, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestIsTokenRejected(t *testing.T) {
+	retrieveErr := func(status int, code string) error {
+		return &url.Error{Op: "Get", URL: "https://api.spotify.com/v1/me", Err: &oauth2.RetrieveError{
+			Response:  &http.Response{StatusCode: status},
+			ErrorCode: code,
+		}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"invalid grant", retrieveErr(400, "invalid_grant"), true},
+		{"bad request", retrieveErr(400, ""), true},
+		{"invalid client", retrieveErr(401, "invalid_client"), true},
+		{"unavailable", retrieveErr(503, ""), false},
+		{"server error", retrieveErr(500, "server_error"), false},
+		{"network error", &url.Error{Op: "Post", URL: "https://accounts.spotify.com/api/token", Err: errors.New("connection reset")}, false},
+		{"other error", fmt.Errorf("failed: %w", io.ErrUnexpectedEOF), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTokenRejected(tt.err); got != tt.want {
+				t.Errorf("isTokenRejected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
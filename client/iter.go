@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// RecentlyPlayedAll follows the before cursors of recently played, Spotify only keeps the last 50 plays
+func (s *SpotifyClient) RecentlyPlayedAll(ctx context.Context, opts ...func(*RequestOptions)) iter.Seq2[*RecentlyPlayed, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(recentlyPlayedPath), options.cursorParams(), options.MaxItems,
+		func(page *SpotifyRecentlyPlayedTracks) ([]*RecentlyPlayed, string) {
+			converted := page.Convert()
+			if converted == nil {
+				return nil, page.Next
+			}
+			s.resolveContextNames(ctx, converted.RecentlyPlayed)
+			return converted.RecentlyPlayed, page.Next
+		})
+}
+
+func (s *SpotifyClient) TopTracksAll(ctx context.Context, opts ...func(*RequestOptions)) iter.Seq2[*Track, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(topTracksPath), options.topParams(), options.MaxItems,
+		func(page *SpotifyTopTracks) ([]*Track, string) {
+			return convertTracks(page.Tracks), page.Next
+		})
+}
+
+func (s *SpotifyClient) TopArtistsAll(ctx context.Context, opts ...func(*RequestOptions)) iter.Seq2[*Artist, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(topArtistsPath), options.topParams(), options.MaxItems,
+		func(page *SpotifyTopArtists) ([]*Artist, string) {
+			return convertArtists(page.Artists), page.Next
+		})
+}
+
+func (s *SpotifyClient) SavedTracksAll(ctx context.Context, opts ...func(*RequestOptions)) iter.Seq2[*SavedTrack, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(savedTracksPath), options.offsetParams(), options.MaxItems,
+		func(page *SpotifySavedTracks) ([]*SavedTrack, string) {
+			return page.Convert().Tracks, page.Next
+		})
+}
+
+func (s *SpotifyClient) SavedAlbumsAll(ctx context.Context, opts ...func(*RequestOptions)) iter.Seq2[*SavedAlbum, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(savedAlbumsPath), options.offsetParams(), options.MaxItems,
+		func(page *SpotifySavedAlbums) ([]*SavedAlbum, string) {
+			return page.Convert().Albums, page.Next
+		})
+}
+
+func (s *SpotifyClient) SavedShowsAll(ctx context.Context, opts ...func(*RequestOptions)) iter.Seq2[*SavedShow, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(savedShowsPath), options.offsetParams(), options.MaxItems,
+		func(page *SpotifySavedShows) ([]*SavedShow, string) {
+			return page.Convert().Shows, page.Next
+		})
+}
+
+func (s *SpotifyClient) PlaylistsAll(ctx context.Context, opts ...func(*RequestOptions)) iter.Seq2[*Playlist, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(playlistsPath), options.offsetParams(), options.MaxItems,
+		func(page *SpotifyPlaylists) ([]*Playlist, string) {
+			return page.Convert().Playlists, page.Next
+		})
+}
+
+func (s *SpotifyClient) PlaylistItemsAll(ctx context.Context, playlistID string, opts ...func(*RequestOptions)) iter.Seq2[*PlaylistItem, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(playlistItemsPath(playlistID)), playlistItemsParams(options), options.MaxItems,
+		func(page *SpotifyPlaylistItems) ([]*PlaylistItem, string) {
+			return page.Convert().Items, page.Next
+		})
+}
+
+func (s *SpotifyClient) FollowedArtistsAll(ctx context.Context, opts ...func(*RequestOptions)) iter.Seq2[*Artist, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(followingPath), followedArtistsParams(options), options.MaxItems,
+		func(page *SpotifyFollowedArtists) ([]*Artist, string) {
+			return convertArtists(page.Artists.Items), page.Artists.Next
+		})
+}
+
+func (s *SpotifyClient) ArtistAlbumsAll(ctx context.Context, artistID string, opts ...func(*RequestOptions)) iter.Seq2[*Album, error] {
+	options := s.pageOptions(opts)
+	return paginate(ctx, s, s.endpoint(artistPath+url.PathEscape(artistID)+"/albums"), artistAlbumsParams(options), options.MaxItems,
+		func(page *SpotifyAlbums) ([]*Album, string) {
+			return page.Convert().Albums, page.Next
+		})
+}
+
+// paginate fetches pages by following Spotify's next links until they run out,
+// maxItems have been yielded, the context is cancelled or the caller stops iterating.
+// An error is yielded once and ends the iteration
+func paginate[P any, T any](ctx context.Context, s *SpotifyClient, start string, initial url.Values, maxItems int, items func(*P) ([]T, string)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		// Every range starts over from the first page
+		next, params := start, initial
+		var zero T
+		count := 0
+
+		for next != "" && (maxItems == 0 || count < maxItems) {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			page := new(P)
+			if err := s.doRequest(ctx, http.MethodGet, next, params, nil, page); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			var pageItems []T
+			pageItems, next = items(page)
+			for _, item := range pageItems {
+				if maxItems > 0 && count >= maxItems {
+					return
+				}
+				if !yield(item, nil) {
+					return
+				}
+				count++
+			}
+
+			// Next links already carry the query parameters
+			params = nil
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// topTracksPages serves pages of two tracks, linking each page to the next
+func topTracksPages(t *testing.T, total int, failPage int) (http.Handler, *[]string) {
+	t.Helper()
+
+	var queries []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != topTracksPath {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		queries = append(queries, r.URL.RawQuery)
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == failPage {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		var items []string
+		for i := page * 2; i < min(page*2+2, total); i++ {
+			items = append(items, fmt.Sprintf(`{"id": "track-%d", "name": "Track %d"}`, i, i))
+		}
+		next := "null"
+		if (page+1)*2 < total {
+			next = fmt.Sprintf(`"http://%s%s?page=%d"`, r.Host, topTracksPath, page+1)
+		}
+		fmt.Fprintf(w, `{"items": [%s], "next": %s, "total": %d}`, strings.Join(items, ","), next, total)
+	})
+	return handler, &queries
+}
+
+func TestPaginate(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		maxItems  int
+		stopAfter int
+		failPage  int
+		wantIDs   []string
+		wantPages int
+		wantLimit string
+		wantErr   bool
+	}{
+		{"every page", 5, 0, 0, -1, []string{"track-0", "track-1", "track-2", "track-3", "track-4"}, 3, "50", false},
+		{"single page", 2, 0, 0, -1, []string{"track-0", "track-1"}, 1, "50", false},
+		{"empty", 0, 0, 0, -1, nil, 1, "50", false},
+		{"max items mid page", 5, 3, 0, -1, []string{"track-0", "track-1", "track-2"}, 2, "3", false},
+		{"max items on a page boundary", 5, 2, 0, -1, []string{"track-0", "track-1"}, 1, "2", false},
+		{"max items above total", 3, 10, 0, -1, []string{"track-0", "track-1", "track-2"}, 2, "10", false},
+		{"caller stops", 5, 0, 1, -1, []string{"track-0"}, 1, "50", false},
+		{"error on a later page", 5, 0, 0, 1, []string{"track-0", "track-1"}, 2, "50", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, queries := topTracksPages(t, tt.total, tt.failPage)
+			spotifyClient := newTestClient(t, handler, http.StatusOK)
+
+			var ids []string
+			var gotErr error
+			for track, err := range spotifyClient.TopTracksAll(context.Background(), MaxItems(tt.maxItems)) {
+				if err != nil {
+					gotErr = err
+					break
+				}
+				ids = append(ids, track.ID)
+				if tt.stopAfter > 0 && len(ids) == tt.stopAfter {
+					break
+				}
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", gotErr, tt.wantErr)
+			}
+			var apiErr *APIError
+			if tt.wantErr && (!errors.As(gotErr, &apiErr) || apiErr.StatusCode != http.StatusNotFound) {
+				t.Errorf("error = %v, want a 404 APIError", gotErr)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("ids = %v, want %v", ids, tt.wantIDs)
+			}
+
+			if len(*queries) != tt.wantPages {
+				t.Fatalf("fetched %d pages, want %d: %v", len(*queries), tt.wantPages, *queries)
+			}
+			first, err := url.ParseQuery((*queries)[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if limit := first.Get("limit"); limit != tt.wantLimit {
+				t.Errorf("limit = %q, want %q", limit, tt.wantLimit)
+			}
+			// Later pages are fetched from the next links as they are
+			for i, query := range (*queries)[1:] {
+				if want := fmt.Sprintf("page=%d", i+1); query != want {
+					t.Errorf("page %d query = %q, want %q", i+1, query, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPaginateCancelled(t *testing.T) {
+	handler, queries := topTracksPages(t, 5, -1)
+	spotifyClient := newTestClient(t, handler, http.StatusOK)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var gotErr error
+	for _, err := range spotifyClient.TopTracksAll(ctx) {
+		if err != nil {
+			gotErr = err
+			break
+		}
+		cancel()
+	}
+
+	if !errors.Is(gotErr, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", gotErr)
+	}
+	if len(*queries) != 1 {
+		t.Errorf("fetched %d pages, want 1", len(*queries))
+	}
+}
+
+func TestPaginateTwice(t *testing.T) {
+	tests := []struct {
+		name      string
+		stopAfter int
+	}{
+		{"after a full range", 0},
+		{"after stopping mid page", 1},
+		{"after stopping on a later page", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, queries := topTracksPages(t, 5, -1)
+			spotifyClient := newTestClient(t, handler, http.StatusOK)
+			tracks := spotifyClient.TopTracksAll(context.Background())
+
+			for track, err := range tracks {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if track.ID == fmt.Sprintf("track-%d", tt.stopAfter-1) {
+					break
+				}
+			}
+
+			*queries = nil
+			var ids []string
+			for track, err := range tracks {
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, track.ID)
+			}
+
+			want := []string{"track-0", "track-1", "track-2", "track-3", "track-4"}
+			if !slices.Equal(ids, want) {
+				t.Errorf("second range = %v, want %v", ids, want)
+			}
+			if len(*queries) == 0 {
+				t.Fatal("second range fetched nothing")
+			}
+			if first, _ := url.ParseQuery((*queries)[0]); first.Get("limit") != "50" {
+				t.Errorf("second range started at %q, want the first page", (*queries)[0])
+			}
+		})
+	}
+}
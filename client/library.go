@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// The library endpoints need the user-library-read scope
+const (
+	savedTracksPath = "/me/tracks"
+	savedAlbumsPath = "/me/albums"
+	savedShowsPath  = "/me/shows"
+)
+
+// GetSavedTracks returns liked songs, most recently saved first
+func (s *SpotifyClient) GetSavedTracks(ctx context.Context, opts ...func(*RequestOptions)) (*SavedTracks, error) {
+	st := &SpotifySavedTracks{}
+	options := s.requestOptions(opts)
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(savedTracksPath), options.offsetParams(), nil, st)
+	if err != nil {
+		return nil, err
+	}
+	return st.Convert(), nil
+}
+
+func (s *SpotifyClient) GetSavedAlbums(ctx context.Context, opts ...func(*RequestOptions)) (*SavedAlbums, error) {
+	sa := &SpotifySavedAlbums{}
+	options := s.requestOptions(opts)
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(savedAlbumsPath), options.offsetParams(), nil, sa)
+	if err != nil {
+		return nil, err
+	}
+	return sa.Convert(), nil
+}
+
+func (s *SpotifyClient) GetSavedShows(ctx context.Context, opts ...func(*RequestOptions)) (*SavedShows, error) {
+	ss := &SpotifySavedShows{}
+	options := s.requestOptions(opts)
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(savedShowsPath), options.offsetParams(), nil, ss)
+	if err != nil {
+		return nil, err
+	}
+	return ss.Convert(), nil
+}
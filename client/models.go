@@ -1,24 +1,236 @@
 // Simplifies Spotify API responses for consumption
 package client
 
+import (
+	"slices"
+	"time"
+)
+
 type Track struct {
 	Name       string    `json:"name"`
 	Artists    []*Artist `json:"artists"`
 	SpotifyUrl *string   `json:"spotify_url"`
+	ID         string    `json:"id,omitempty"`
+	URI        string    `json:"uri,omitempty"`
+	Album      *Album    `json:"album,omitempty"`
+	DurationMs int       `json:"duration_ms,omitempty"`
+	Explicit   bool      `json:"explicit,omitempty"`
+	Popularity int       `json:"popularity,omitempty"`
+	PreviewUrl *string   `json:"preview_url,omitempty"`
+	ISRC       string    `json:"isrc,omitempty"`
 }
 
 type Artist struct {
-	Name       string  `json:"name"`
-	SpotifyUrl *string `json:"spotify_url"`
+	Name       string   `json:"name"`
+	SpotifyUrl *string  `json:"spotify_url"`
+	ID         string   `json:"id,omitempty"`
+	URI        string   `json:"uri,omitempty"`
+	Genres     []string `json:"genres,omitempty"`
+	Images     []*Image `json:"images,omitempty"`
+	Popularity int      `json:"popularity,omitempty"`
+	// Followers is only known for full artist objects, e.g. top artists
+	Followers *int `json:"followers,omitempty"`
+}
+
+type Album struct {
+	Name        string    `json:"name"`
+	SpotifyUrl  *string   `json:"spotify_url"`
+	ID          string    `json:"id,omitempty"`
+	URI         string    `json:"uri,omitempty"`
+	AlbumType   string    `json:"album_type,omitempty"`
+	ReleaseDate string    `json:"release_date,omitempty"`
+	TotalTracks int       `json:"total_tracks,omitempty"`
+	Images      []*Image  `json:"images,omitempty"`
+	Artists     []*Artist `json:"artists,omitempty"`
+}
+
+// Images are ordered widest first, as returned by Spotify
+type Image struct {
+	URL    string `json:"url"`
+	Height int    `json:"height,omitempty"`
+	Width  int    `json:"width,omitempty"`
+}
+
+type Episode struct {
+	Name        string   `json:"name"`
+	SpotifyUrl  *string  `json:"spotify_url"`
+	ID          string   `json:"id,omitempty"`
+	URI         string   `json:"uri,omitempty"`
+	Description string   `json:"description,omitempty"`
+	DurationMs  int      `json:"duration_ms,omitempty"`
+	Explicit    bool     `json:"explicit,omitempty"`
+	ReleaseDate string   `json:"release_date,omitempty"`
+	Images      []*Image `json:"images,omitempty"`
+	Show        *Show    `json:"show,omitempty"`
 }
 
+type Show struct {
+	Name          string   `json:"name"`
+	SpotifyUrl    *string  `json:"spotify_url"`
+	ID            string   `json:"id,omitempty"`
+	URI           string   `json:"uri,omitempty"`
+	Publisher     string   `json:"publisher,omitempty"`
+	Description   string   `json:"description,omitempty"`
+	TotalEpisodes int      `json:"total_episodes,omitempty"`
+	Images        []*Image `json:"images,omitempty"`
+}
+
+// CurrentlyPlaying has either Track or Episode set, depending on Type
 type CurrentlyPlaying struct {
-	Progress int    `json:"progress_ms"`
-	Track    *Track `json:"track"`
+	Progress  int              `json:"progress_ms"`
+	Track     *Track           `json:"track"`
+	Episode   *Episode         `json:"episode,omitempty"`
+	Type      string           `json:"type,omitempty"`
+	IsPlaying bool             `json:"is_playing"`
+	Timestamp time.Time        `json:"timestamp,omitzero"`
+	Context   *PlaybackContext `json:"context,omitempty"`
+}
+
+type FollowedArtists struct {
+	Artists []*Artist `json:"artists"`
+	Total   int       `json:"total"`
+	// Cursor fetches the next page when passed to Cursor, it's empty on the last page
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type Albums struct {
+	Albums []*Album `json:"albums"`
+	Total  int      `json:"total"`
+}
+
+// SearchResult only has the types that were searched for
+type SearchResult struct {
+	Tracks    []*Track    `json:"tracks,omitempty"`
+	Artists   []*Artist   `json:"artists,omitempty"`
+	Albums    []*Album    `json:"albums,omitempty"`
+	Playlists []*Playlist `json:"playlists,omitempty"`
+	Shows     []*Show     `json:"shows,omitempty"`
+	Episodes  []*Episode  `json:"episodes,omitempty"`
+}
+
+// Saved items keep the item fields at the top level, alongside when they were saved
+type SavedTrack struct {
+	*Track
+	AddedAt time.Time `json:"added_at"`
+}
+
+type SavedTracks struct {
+	Tracks []*SavedTrack `json:"tracks"`
+	Total  int           `json:"total"`
+}
+
+type SavedAlbum struct {
+	*Album
+	AddedAt time.Time `json:"added_at"`
+}
+
+type SavedAlbums struct {
+	Albums []*SavedAlbum `json:"albums"`
+	Total  int           `json:"total"`
+}
+
+type SavedShow struct {
+	*Show
+	AddedAt time.Time `json:"added_at"`
+}
+
+type SavedShows struct {
+	Shows []*SavedShow `json:"shows"`
+	Total int          `json:"total"`
+}
+
+// User only has images, followers, country and product for the current user's profile
+type User struct {
+	ID          string   `json:"id"`
+	DisplayName string   `json:"display_name,omitempty"`
+	SpotifyUrl  *string  `json:"spotify_url"`
+	URI         string   `json:"uri,omitempty"`
+	Images      []*Image `json:"images,omitempty"`
+	Followers   *int     `json:"followers,omitempty"`
+	Country     string   `json:"country,omitempty"`
+	// Product is the subscription level, e.g. premium or free
+	Product string `json:"product,omitempty"`
+}
+
+type Playlist struct {
+	Name          string  `json:"name"`
+	SpotifyUrl    *string `json:"spotify_url"`
+	ID            string  `json:"id,omitempty"`
+	URI           string  `json:"uri,omitempty"`
+	Description   string  `json:"description,omitempty"`
+	Public        *bool   `json:"public,omitempty"`
+	Collaborative bool    `json:"collaborative,omitempty"`
+	// SnapshotID changes whenever the playlist's items change
+	SnapshotID  string   `json:"snapshot_id,omitempty"`
+	Owner       *User    `json:"owner,omitempty"`
+	Images      []*Image `json:"images,omitempty"`
+	TotalTracks int      `json:"total_tracks"`
+}
+
+type Playlists struct {
+	Playlists []*Playlist `json:"playlists"`
+	Total     int         `json:"total"`
+}
+
+// PlaylistItem keeps the item fields at the top level, alongside who added it and when
+type PlaylistItem struct {
+	*PlayableItem
+	AddedAt time.Time `json:"added_at"`
+	AddedBy *User     `json:"added_by,omitempty"`
+	IsLocal bool      `json:"is_local,omitempty"`
+}
+
+type PlaylistItems struct {
+	Items []*PlaylistItem `json:"items"`
+	Total int             `json:"total"`
+}
+
+// PlayableItem is a track or a podcast episode, depending on Type
+type PlayableItem struct {
+	Type    string   `json:"type"`
+	Track   *Track   `json:"track,omitempty"`
+	Episode *Episode `json:"episode,omitempty"`
+}
+
+type Queue struct {
+	CurrentlyPlaying *PlayableItem   `json:"currently_playing"`
+	Queue            []*PlayableItem `json:"queue"`
+}
+
+type PlaybackState struct {
+	*CurrentlyPlaying
+	Device  *Device `json:"device,omitempty"`
+	Shuffle bool    `json:"shuffle"`
+	// Repeat is one of off, track or context
+	Repeat string `json:"repeat"`
+}
+
+type Device struct {
+	ID            string `json:"id,omitempty"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	IsActive      bool   `json:"is_active"`
+	IsRestricted  bool   `json:"is_restricted,omitempty"`
+	VolumePercent *int   `json:"volume_percent,omitempty"`
 }
 
 type RecentlyPlayedTracks struct {
-	RecentlyPlayed []*Track `json:"tracks"`
+	RecentlyPlayed []*RecentlyPlayed `json:"tracks"`
+}
+
+// RecentlyPlayed keeps the track fields at the top level so older consumers still work
+type RecentlyPlayed struct {
+	*Track
+	PlayedAt time.Time        `json:"played_at"`
+	Context  *PlaybackContext `json:"context,omitempty"`
+}
+
+// PlaybackContext is the playlist, album, artist or show a track was played from
+type PlaybackContext struct {
+	Type       string  `json:"type"`
+	URI        string  `json:"uri"`
+	Name       string  `json:"name,omitempty"`
+	SpotifyUrl *string `json:"spotify_url"`
 }
 
 type TopTracks struct {
@@ -43,13 +255,34 @@ func (a *SpotifyArtist) SpotifyUrl() string {
 	return a.ExternalURLs["spotify"]
 }
 
+func (a *SpotifyAlbum) SpotifyUrl() string {
+	if a == nil {
+		return ""
+	}
+	return a.ExternalURLs["spotify"]
+}
+
+func (e *SpotifyEpisode) SpotifyUrl() string {
+	if e == nil {
+		return ""
+	}
+	return e.ExternalURLs["spotify"]
+}
+
+func (s *SpotifyShow) SpotifyUrl() string {
+	if s == nil {
+		return ""
+	}
+	return s.ExternalURLs["spotify"]
+}
+
 func (r *SpotifyRecentlyPlayedTracks) Convert() *RecentlyPlayedTracks {
 	if r == nil || r.RecentlyPlayed == nil {
 		return nil
 	}
-	tracks := make([]*Track, 0, len(r.RecentlyPlayed))
+	tracks := make([]*RecentlyPlayed, 0, len(r.RecentlyPlayed))
 	for _, item := range r.RecentlyPlayed {
-		tracks = append(tracks, item.Track.convert())
+		tracks = append(tracks, item.Convert())
 	}
 	return &RecentlyPlayedTracks{
 		RecentlyPlayed: tracks,
@@ -60,9 +293,307 @@ func (c *SpotifyCurrentlyPlaying) Convert() *CurrentlyPlaying {
 	if c == nil {
 		return nil
 	}
-	return &CurrentlyPlaying{
-		Progress: c.Progress,
-		Track:    c.Item.convert(),
+	currentlyPlaying := &CurrentlyPlaying{
+		Progress:  c.Progress,
+		Type:      c.CurrentlyPlayingType,
+		IsPlaying: c.IsPlaying,
+		Context:   c.Context.convert(),
+	}
+	if c.Timestamp != 0 {
+		currentlyPlaying.Timestamp = time.UnixMilli(c.Timestamp)
+	}
+	if c.Item != nil {
+		currentlyPlaying.Track = c.Item.Track.convert()
+		currentlyPlaying.Episode = c.Item.Episode.convert()
+	}
+	return currentlyPlaying
+}
+
+func (f *SpotifyFollowedArtists) Convert() *FollowedArtists {
+	if f == nil {
+		return nil
+	}
+	followed := &FollowedArtists{
+		Artists: convertArtists(f.Artists.Items),
+		Total:   f.Artists.Total,
+	}
+	if f.Artists.Next != "" && f.Artists.Cursors != nil {
+		followed.Cursor = f.Artists.Cursors.After
+	}
+	return followed
+}
+
+func (a *SpotifyAlbums) Convert() *Albums {
+	if a == nil {
+		return nil
+	}
+	albums := make([]*Album, 0, len(a.Items))
+	for _, album := range a.Items {
+		albums = append(albums, album.convert())
+	}
+	return &Albums{
+		Albums: albums,
+		Total:  a.Total,
+	}
+}
+
+func (r *SpotifySearchResult) Convert() *SearchResult {
+	if r == nil {
+		return nil
+	}
+	// Spotify pads some result lists with nulls
+	result := &SearchResult{}
+	if r.Tracks != nil {
+		result.Tracks = convertTracks(slices.DeleteFunc(r.Tracks.Items, isNil))
+	}
+	if r.Artists != nil {
+		result.Artists = convertArtists(slices.DeleteFunc(r.Artists.Items, isNil))
+	}
+	if r.Albums != nil {
+		for _, album := range slices.DeleteFunc(r.Albums.Items, isNil) {
+			result.Albums = append(result.Albums, album.convert())
+		}
+	}
+	if r.Playlists != nil {
+		for _, playlist := range slices.DeleteFunc(r.Playlists.Items, isNil) {
+			result.Playlists = append(result.Playlists, playlist.Convert())
+		}
+	}
+	if r.Shows != nil {
+		for _, show := range slices.DeleteFunc(r.Shows.Items, isNil) {
+			result.Shows = append(result.Shows, show.convert())
+		}
+	}
+	if r.Episodes != nil {
+		for _, episode := range slices.DeleteFunc(r.Episodes.Items, isNil) {
+			result.Episodes = append(result.Episodes, episode.convert())
+		}
+	}
+	return result
+}
+
+func isNil[T any](item *T) bool {
+	return item == nil
+}
+
+func (t *SpotifySavedTracks) Convert() *SavedTracks {
+	if t == nil {
+		return nil
+	}
+	tracks := make([]*SavedTrack, 0, len(t.Items))
+	for _, item := range t.Items {
+		tracks = append(tracks, item.convert())
+	}
+	return &SavedTracks{
+		Tracks: tracks,
+		Total:  t.Total,
+	}
+}
+
+func (t *SpotifySavedTrack) convert() *SavedTrack {
+	if t == nil {
+		return nil
+	}
+	return &SavedTrack{
+		Track:   t.Track.convert(),
+		AddedAt: t.AddedAt,
+	}
+}
+
+func (a *SpotifySavedAlbums) Convert() *SavedAlbums {
+	if a == nil {
+		return nil
+	}
+	albums := make([]*SavedAlbum, 0, len(a.Items))
+	for _, item := range a.Items {
+		albums = append(albums, item.convert())
+	}
+	return &SavedAlbums{
+		Albums: albums,
+		Total:  a.Total,
+	}
+}
+
+func (a *SpotifySavedAlbum) convert() *SavedAlbum {
+	if a == nil {
+		return nil
+	}
+	return &SavedAlbum{
+		Album:   a.Album.convert(),
+		AddedAt: a.AddedAt,
+	}
+}
+
+func (s *SpotifySavedShows) Convert() *SavedShows {
+	if s == nil {
+		return nil
+	}
+	shows := make([]*SavedShow, 0, len(s.Items))
+	for _, item := range s.Items {
+		shows = append(shows, item.convert())
+	}
+	return &SavedShows{
+		Shows: shows,
+		Total: s.Total,
+	}
+}
+
+func (s *SpotifySavedShow) convert() *SavedShow {
+	if s == nil {
+		return nil
+	}
+	return &SavedShow{
+		Show:    s.Show.convert(),
+		AddedAt: s.AddedAt,
+	}
+}
+
+func (u *SpotifyUser) SpotifyUrl() string {
+	if u == nil {
+		return ""
+	}
+	return u.ExternalURLs["spotify"]
+}
+
+func (u *SpotifyUser) convert() *User {
+	if u == nil {
+		return nil
+	}
+	url := u.SpotifyUrl()
+	user := &User{
+		ID:          u.ID,
+		DisplayName: u.DisplayName,
+		SpotifyUrl:  &url,
+		URI:         u.URI,
+		Images:      convertImages(u.Images),
+		Country:     u.Country,
+		Product:     u.Product,
+	}
+	if u.Followers != nil {
+		followers := u.Followers.Total
+		user.Followers = &followers
+	}
+	return user
+}
+
+func (p *SpotifyPlaylist) SpotifyUrl() string {
+	if p == nil {
+		return ""
+	}
+	return p.ExternalURLs["spotify"]
+}
+
+func (p *SpotifyPlaylist) Convert() *Playlist {
+	if p == nil {
+		return nil
+	}
+	url := p.SpotifyUrl()
+	playlist := &Playlist{
+		Name:          p.Name,
+		SpotifyUrl:    &url,
+		ID:            p.ID,
+		URI:           p.URI,
+		Description:   p.Description,
+		Public:        p.Public,
+		Collaborative: p.Collaborative,
+		SnapshotID:    p.SnapshotID,
+		Owner:         p.Owner.convert(),
+		Images:        convertImages(p.Images),
+	}
+	if p.Tracks != nil {
+		playlist.TotalTracks = p.Tracks.Total
+	}
+	return playlist
+}
+
+func (p *SpotifyPlaylists) Convert() *Playlists {
+	if p == nil {
+		return nil
+	}
+	playlists := make([]*Playlist, 0, len(p.Items))
+	for _, playlist := range p.Items {
+		playlists = append(playlists, playlist.Convert())
+	}
+	return &Playlists{
+		Playlists: playlists,
+		Total:     p.Total,
+	}
+}
+
+func (p *SpotifyPlaylistItems) Convert() *PlaylistItems {
+	if p == nil {
+		return nil
+	}
+	items := make([]*PlaylistItem, 0, len(p.Items))
+	for _, item := range p.Items {
+		items = append(items, item.convert())
+	}
+	return &PlaylistItems{
+		Items: items,
+		Total: p.Total,
+	}
+}
+
+func (i *SpotifyPlaylistItem) convert() *PlaylistItem {
+	if i == nil {
+		return nil
+	}
+	return &PlaylistItem{
+		PlayableItem: i.Item.convert(),
+		AddedAt:      i.AddedAt,
+		AddedBy:      i.AddedBy.convert(),
+		IsLocal:      i.IsLocal,
+	}
+}
+
+func (q *SpotifyQueue) Convert() *Queue {
+	if q == nil {
+		return nil
+	}
+	items := make([]*PlayableItem, 0, len(q.Queue))
+	for _, item := range q.Queue {
+		items = append(items, item.convert())
+	}
+	return &Queue{
+		CurrentlyPlaying: q.CurrentlyPlaying.convert(),
+		Queue:            items,
+	}
+}
+
+func (i *SpotifyItem) convert() *PlayableItem {
+	if i == nil {
+		return nil
+	}
+	return &PlayableItem{
+		Type:    i.Type,
+		Track:   i.Track.convert(),
+		Episode: i.Episode.convert(),
+	}
+}
+
+func (p *SpotifyPlaybackState) Convert() *PlaybackState {
+	if p == nil {
+		return nil
+	}
+	return &PlaybackState{
+		CurrentlyPlaying: p.SpotifyCurrentlyPlaying.Convert(),
+		Device:           p.Device.convert(),
+		Shuffle:          p.ShuffleState,
+		Repeat:           p.RepeatState,
+	}
+}
+
+func (d *SpotifyDevice) convert() *Device {
+	if d == nil {
+		return nil
+	}
+	return &Device{
+		ID:            d.ID,
+		Name:          d.Name,
+		Type:          d.Type,
+		IsActive:      d.IsActive,
+		IsRestricted:  d.IsRestricted,
+		VolumePercent: d.VolumePercent,
 	}
 }
 
@@ -92,11 +623,34 @@ func (a *SpotifyTopArtists) Convert() *TopArtists {
 	}
 }
 
-func (r *SpotifyRecentlyPlayed) Convert() *Track {
+func (r *SpotifyRecentlyPlayed) Convert() *RecentlyPlayed {
 	if r == nil {
 		return nil
 	}
-	return r.Track.convert()
+	return &RecentlyPlayed{
+		Track:    r.Track.convert(),
+		PlayedAt: r.PlayedAt,
+		Context:  r.Context.convert(),
+	}
+}
+
+func (c *SpotifyContext) SpotifyUrl() string {
+	if c == nil {
+		return ""
+	}
+	return c.ExternalURLs["spotify"]
+}
+
+func (c *SpotifyContext) convert() *PlaybackContext {
+	if c == nil {
+		return nil
+	}
+	url := c.SpotifyUrl()
+	return &PlaybackContext{
+		Type:       c.Type,
+		URI:        c.URI,
+		SpotifyUrl: &url,
+	}
 }
 
 func convertArtists(artists []*SpotifyArtist) []*Artist {
@@ -130,6 +684,14 @@ func (s *SpotifyTrack) convert() *Track {
 		Name:       s.Name,
 		Artists:    convertArtists(s.Artists),
 		SpotifyUrl: &url,
+		ID:         s.ID,
+		URI:        s.URI,
+		Album:      s.Album.convert(),
+		DurationMs: s.DurationMs,
+		Explicit:   s.Explicit,
+		Popularity: s.Popularity,
+		PreviewUrl: s.PreviewURL,
+		ISRC:       s.ExternalIDs["isrc"],
 	}
 }
 
@@ -138,8 +700,90 @@ func (a *SpotifyArtist) convert() *Artist {
 		return nil
 	}
 	url := a.SpotifyUrl()
-	return &Artist{
+	artist := &Artist{
 		Name:       a.Name,
 		SpotifyUrl: &url,
+		ID:         a.ID,
+		URI:        a.URI,
+		Genres:     a.Genres,
+		Images:     convertImages(a.Images),
+		Popularity: a.Popularity,
+	}
+	if a.Followers != nil {
+		followers := a.Followers.Total
+		artist.Followers = &followers
+	}
+	return artist
+}
+
+func (a *SpotifyAlbum) convert() *Album {
+	if a == nil {
+		return nil
+	}
+	url := a.SpotifyUrl()
+	return &Album{
+		Name:        a.Name,
+		SpotifyUrl:  &url,
+		ID:          a.ID,
+		URI:         a.URI,
+		AlbumType:   a.AlbumType,
+		ReleaseDate: a.ReleaseDate,
+		TotalTracks: a.TotalTracks,
+		Images:      convertImages(a.Images),
+		Artists:     convertArtists(a.Artists),
+	}
+}
+
+func (e *SpotifyEpisode) convert() *Episode {
+	if e == nil {
+		return nil
 	}
+	url := e.SpotifyUrl()
+	return &Episode{
+		Name:        e.Name,
+		SpotifyUrl:  &url,
+		ID:          e.ID,
+		URI:         e.URI,
+		Description: e.Description,
+		DurationMs:  e.DurationMs,
+		Explicit:    e.Explicit,
+		ReleaseDate: e.ReleaseDate,
+		Images:      convertImages(e.Images),
+		Show:        e.Show.convert(),
+	}
+}
+
+func (s *SpotifyShow) convert() *Show {
+	if s == nil {
+		return nil
+	}
+	url := s.SpotifyUrl()
+	return &Show{
+		Name:          s.Name,
+		SpotifyUrl:    &url,
+		ID:            s.ID,
+		URI:           s.URI,
+		Publisher:     s.Publisher,
+		Description:   s.Description,
+		TotalEpisodes: s.TotalEpisodes,
+		Images:        convertImages(s.Images),
+	}
+}
+
+func convertImages(images []*SpotifyImage) []*Image {
+	if images == nil {
+		return nil
+	}
+	converted := make([]*Image, 0, len(images))
+	for _, image := range images {
+		if image == nil {
+			continue
+		}
+		converted = append(converted, &Image{
+			URL:    image.URL,
+			Height: image.Height,
+			Width:  image.Width,
+		})
+	}
+	return converted
 }
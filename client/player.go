@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	pausePath    = "/me/player/pause"
+	playPath     = "/me/player/play"
+	nextPath     = "/me/player/next"
+	previousPath = "/me/player/previous"
+	seekPath     = "/me/player/seek"
+	queuePath    = "/me/player/queue"
+)
+
+// Player commands need the user-modify-playback-state scope and a Premium account.
+// They act on the active device unless OnDevice is given
+
+func (s *SpotifyClient) Pause(ctx context.Context, opts ...func(*RequestOptions)) error {
+	params := s.requestOptions(opts).deviceParams()
+	return s.doRequest(ctx, http.MethodPut, s.endpoint(pausePath), params, nil, nil)
+}
+
+func (s *SpotifyClient) Resume(ctx context.Context, opts ...func(*RequestOptions)) error {
+	params := s.requestOptions(opts).deviceParams()
+	return s.doRequest(ctx, http.MethodPut, s.endpoint(playPath), params, nil, nil)
+}
+
+func (s *SpotifyClient) Next(ctx context.Context, opts ...func(*RequestOptions)) error {
+	params := s.requestOptions(opts).deviceParams()
+	return s.doRequest(ctx, http.MethodPost, s.endpoint(nextPath), params, nil, nil)
+}
+
+func (s *SpotifyClient) Previous(ctx context.Context, opts ...func(*RequestOptions)) error {
+	params := s.requestOptions(opts).deviceParams()
+	return s.doRequest(ctx, http.MethodPost, s.endpoint(previousPath), params, nil, nil)
+}
+
+func (s *SpotifyClient) Seek(ctx context.Context, position time.Duration, opts ...func(*RequestOptions)) error {
+	params := s.requestOptions(opts).deviceParams()
+	params.Set("position_ms", strconv.FormatInt(position.Milliseconds(), 10))
+	return s.doRequest(ctx, http.MethodPut, s.endpoint(seekPath), params, nil, nil)
+}
+
+// AddToQueue queues a track or episode URI, e.g. spotify:track:{id}
+func (s *SpotifyClient) AddToQueue(ctx context.Context, uri string, opts ...func(*RequestOptions)) error {
+	params := s.requestOptions(opts).deviceParams()
+	params.Set("uri", uri)
+	return s.doRequest(ctx, http.MethodPost, s.endpoint(queuePath), params, nil, nil)
+}
+
+// TransferPlayback moves playback to deviceID, play starts it there even if it was paused
+func (s *SpotifyClient) TransferPlayback(ctx context.Context, deviceID string, play bool) error {
+	body := struct {
+		DeviceIDs []string `json:"device_ids"`
+		Play      bool     `json:"play"`
+	}{
+		DeviceIDs: []string{deviceID},
+		Play:      play,
+	}
+	return s.doRequest(ctx, http.MethodPut, s.endpoint(playerPath), nil, body, nil)
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Private and collaborative playlists need the playlist-read-private scope
+const (
+	playlistsPath = "/me/playlists"
+	playlistPath  = "/playlists/"
+)
+
+// Leaves out the first page of items that Spotify embeds in a full playlist
+const playlistFields = "id,uri,name,description,public,collaborative,snapshot_id,owner,images,external_urls,tracks.total"
+
+func playlistItemsPath(playlistID string) string {
+	return playlistPath + url.PathEscape(playlistID) + "/tracks"
+}
+
+func playlistItemsParams(options *RequestOptions) url.Values {
+	params := options.offsetParams()
+	params.Set("additional_types", "track,episode")
+	return params
+}
+
+// GetPlaylists returns the playlists the user owns or follows
+func (s *SpotifyClient) GetPlaylists(ctx context.Context, opts ...func(*RequestOptions)) (*Playlists, error) {
+	p := &SpotifyPlaylists{}
+	options := s.requestOptions(opts)
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(playlistsPath), options.offsetParams(), nil, p)
+	if err != nil {
+		return nil, err
+	}
+	return p.Convert(), nil
+}
+
+// GetPlaylist returns a playlist's details without its items
+func (s *SpotifyClient) GetPlaylist(ctx context.Context, playlistID string) (*Playlist, error) {
+	p := &SpotifyPlaylist{}
+	params := url.Values{
+		"fields": {playlistFields},
+	}
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(playlistPath+url.PathEscape(playlistID)), params, nil, p)
+	if err != nil {
+		return nil, err
+	}
+	return p.Convert(), nil
+}
+
+// GetPlaylistItems returns a page of a playlist's tracks and episodes
+func (s *SpotifyClient) GetPlaylistItems(ctx context.Context, playlistID string, opts ...func(*RequestOptions)) (*PlaylistItems, error) {
+	pi := &SpotifyPlaylistItems{}
+	options := s.requestOptions(opts)
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(playlistItemsPath(playlistID)), playlistItemsParams(options), nil, pi)
+	if err != nil {
+		return nil, err
+	}
+	return pi.Convert(), nil
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// RequestOptions are per-call parameters, unset fields fall back to the client's Options
+type RequestOptions struct {
+	Limit     int
+	Offset    int
+	TimeRange TimeRange
+	// Before and After are cursors for recently played, only one may be set
+	Before time.Time
+	After  time.Time
+	// Cursor is the ID of the last artist seen when paging followed artists
+	Cursor string
+	// Market is an ISO 3166-1 alpha-2 country code used to filter catalogue results
+	Market string
+	// AlbumGroups filters artist albums, e.g. album, single, appears_on, compilation
+	AlbumGroups []string
+	// DeviceID targets a player command at a device, empty means the active device
+	DeviceID string
+	// MaxItems caps the number of items yielded by the *All iterators, 0 means no cap
+	MaxItems int
+}
+
+func Limit(limit int) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.Limit = clampLimit(limit)
+	}
+}
+
+func Offset(offset int) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.Offset = max(offset, 0)
+	}
+}
+
+func Term(timeRange TimeRange) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.TimeRange = timeRange
+	}
+}
+
+func Before(before time.Time) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.Before = before
+		o.After = time.Time{}
+	}
+}
+
+func After(after time.Time) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.After = after
+		o.Before = time.Time{}
+	}
+}
+
+func MaxItems(maxItems int) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.MaxItems = max(maxItems, 0)
+	}
+}
+
+func Cursor(after string) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.Cursor = after
+	}
+}
+
+func InMarket(market string) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.Market = market
+	}
+}
+
+func AlbumGroups(groups ...string) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.AlbumGroups = groups
+	}
+}
+
+func OnDevice(deviceID string) func(*RequestOptions) {
+	return func(o *RequestOptions) {
+		o.DeviceID = deviceID
+	}
+}
+
+// ParseTimeRange validates a time range coming from user input
+func ParseTimeRange(value string) (TimeRange, bool) {
+	switch timeRange := TimeRange(value); timeRange {
+	case ShortTerm, MediumTerm, LongTerm:
+		return timeRange, true
+	}
+	return "", false
+}
+
+func clampLimit(limit int) int {
+	if limit < 1 {
+		return 1
+	} else if limit > 50 {
+		return 50
+	}
+	return limit
+}
+
+func (s *SpotifyClient) requestOptions(opts []func(*RequestOptions)) *RequestOptions {
+	limit, err := strconv.Atoi(s.options.Limit)
+	if err != nil {
+		limit = 5
+	}
+
+	options := &RequestOptions{
+		Limit:     clampLimit(limit),
+		TimeRange: s.options.TimeRange,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return options
+}
+
+func (o *RequestOptions) topParams() url.Values {
+	return url.Values{
+		"limit":      {strconv.Itoa(o.Limit)},
+		"offset":     {strconv.Itoa(o.Offset)},
+		TimeRangeTag: {string(o.TimeRange)},
+	}
+}
+
+func (o *RequestOptions) offsetParams() url.Values {
+	return url.Values{
+		"limit":  {strconv.Itoa(o.Limit)},
+		"offset": {strconv.Itoa(o.Offset)},
+	}
+}
+
+func (o *RequestOptions) cursorParams() url.Values {
+	params := url.Values{
+		"limit": {strconv.Itoa(o.Limit)},
+	}
+	if !o.Before.IsZero() {
+		params.Set("before", strconv.FormatInt(o.Before.UnixMilli(), 10))
+	} else if !o.After.IsZero() {
+		params.Set("after", strconv.FormatInt(o.After.UnixMilli(), 10))
+	}
+	return params
+}
+
+func (o *RequestOptions) deviceParams() url.Values {
+	params := url.Values{}
+	if o.DeviceID != "" {
+		params.Set("device_id", o.DeviceID)
+	}
+	return params
+}
+
+// pageOptions defaults to the largest page Spotify allows, unless fewer items are wanted
+func (s *SpotifyClient) pageOptions(opts []func(*RequestOptions)) *RequestOptions {
+	options := s.requestOptions(append([]func(*RequestOptions){Limit(50)}, opts...))
+	if options.MaxItems > 0 && options.MaxItems < options.Limit {
+		options.Limit = options.MaxItems
+	}
+	return options
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. 429 responses wait for
+// Retry-After, 5xx responses and network errors back off exponentially with jitter
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt, 0 disables retrying
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry, doubled on every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff, a Retry-After longer than this is returned to the caller instead
+	MaxDelay time.Duration
+	// OnRetry is called before sleeping for each retry, e.g. for logging
+	OnRetry func(attempt int, wait time.Duration, err error)
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   10 * time.Second,
+}
+
+func WithRetryPolicy(policy RetryPolicy) func(*Options) {
+	return func(o *Options) {
+		o.RetryPolicy = policy
+	}
+}
+
+// Retries returns the total number of retries made by the client
+func (s *SpotifyClient) Retries() int64 {
+	return s.retries.Load()
+}
+
+// retryDelay reports how long to wait before retrying err, or false if err
+// should be returned as is. Requests that aren't idempotent are only retried
+// when rate limited, as Spotify hasn't acted on them
+func (p *RetryPolicy) retryDelay(attempt int, idempotent bool, err error) (time.Duration, bool) {
+	if attempt >= p.MaxRetries {
+		return 0, false
+	}
+
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		if rateLimitErr.RetryAfter > p.MaxDelay {
+			return 0, false
+		}
+		if rateLimitErr.RetryAfter > 0 {
+			return rateLimitErr.RetryAfter, true
+		}
+		return p.backoff(attempt), true
+	}
+
+	if !idempotent {
+		return 0, false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return p.backoff(attempt), apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	// A refresh token being rejected won't fix itself
+	if errors.Is(err, ErrUnauthorized) {
+		return 0, false
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return p.backoff(attempt), true
+	}
+
+	return 0, false
+}
+
+// Equal jitter: half the exponential delay is fixed, the other half is random
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half)
+}
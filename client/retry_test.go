@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}
+	urlErr := &url.Error{Op: "Get", URL: "https://api.spotify.com/v1/me", Err: errors.New("connection reset")}
+
+	tests := []struct {
+		name       string
+		attempt    int
+		idempotent bool
+		err        error
+		wantRetry  bool
+		// wantDelay is checked when set, otherwise the delay must be a backoff
+		wantDelay time.Duration
+	}{
+		{"retry after", 0, true, &RateLimitError{APIError: &APIError{StatusCode: 429}, RetryAfter: 2 * time.Second}, true, 2 * time.Second},
+		{"retry after when not idempotent", 0, false, &RateLimitError{APIError: &APIError{StatusCode: 429}, RetryAfter: time.Second}, true, time.Second},
+		{"retry after longer than max delay", 0, true, &RateLimitError{APIError: &APIError{StatusCode: 429}, RetryAfter: time.Minute}, false, 0},
+		{"rate limited without retry after", 1, true, &RateLimitError{APIError: &APIError{StatusCode: 429}}, true, 0},
+		{"server error", 0, true, &APIError{StatusCode: 503}, true, 0},
+		{"server error when not idempotent", 0, false, &APIError{StatusCode: 503}, false, 0},
+		{"client error", 0, true, &APIError{StatusCode: 404}, false, 0},
+		{"rejected refresh token", 0, true, fmt.Errorf("%w: %w", ErrUnauthorized, urlErr), false, 0},
+		{"network error", 2, true, urlErr, true, 0},
+		{"network error when not idempotent", 0, false, urlErr, false, 0},
+		{"out of retries", 3, true, &APIError{StatusCode: 503}, false, 0},
+		{"other error", 0, true, errors.New("failed to decode response"), false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := policy.retryDelay(tt.attempt, tt.idempotent, tt.err)
+			if retry != tt.wantRetry {
+				t.Fatalf("retry = %v, want %v", retry, tt.wantRetry)
+			}
+			if !retry {
+				return
+			}
+
+			if tt.wantDelay != 0 {
+				if delay != tt.wantDelay {
+					t.Errorf("delay = %s, want %s", delay, tt.wantDelay)
+				}
+				return
+			}
+			full := policy.BaseDelay << tt.attempt
+			if delay < full/2 || delay > full {
+				t.Errorf("delay = %s, want between %s and %s", delay, full/2, full)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		attempt int
+		min     time.Duration
+		max     time.Duration
+	}{
+		{0, 50 * time.Millisecond, 100 * time.Millisecond},
+		{1, 100 * time.Millisecond, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond, 800 * time.Millisecond},
+		{4, 500 * time.Millisecond, time.Second},
+		// The shift overflows long before this, it's still capped
+		{70, 500 * time.Millisecond, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.attempt), func(t *testing.T) {
+			for range 100 {
+				if delay := policy.backoff(tt.attempt); delay < tt.min || delay > tt.max {
+					t.Fatalf("backoff(%d) = %s, want between %s and %s", tt.attempt, delay, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name        string
+		statuses    []int
+		tokenStatus int
+		post        bool
+		wantCalls   int64
+		wantRetries int64
+		wantErr     error
+	}{
+		{"success", []int{200}, 200, false, 1, 0, nil},
+		{"server error then success", []int{503, 200}, 200, false, 2, 1, nil},
+		{"rate limited then success", []int{429, 200}, 200, false, 2, 1, nil},
+		{"server error every time", []int{500, 502, 503}, 200, false, 3, 2, &APIError{}},
+		{"not found", []int{404}, 200, false, 1, 0, &APIError{}},
+		{"server error when not idempotent", []int{503, 200}, 200, true, 1, 0, &APIError{}},
+		{"rate limited when not idempotent", []int{429, 204}, 200, true, 2, 1, nil},
+		{"token endpoint unavailable", nil, 503, false, 0, 2, &url.Error{}},
+		{"refresh token rejected", nil, 400, false, 0, 0, ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				call := calls.Add(1)
+				status := tt.statuses[min(int(call), len(tt.statuses))-1]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					fmt.Fprint(w, `{"id": "ash", "display_name": "Ash"}`)
+				}
+			})
+			spotifyClient := newTestClient(t, api, tt.tokenStatus)
+
+			var err error
+			if tt.post {
+				err = spotifyClient.Next(context.Background())
+			} else {
+				_, err = spotifyClient.GetCurrentUser(context.Background())
+			}
+
+			switch target := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			case *APIError:
+				if !errors.As(err, &target) {
+					t.Fatalf("error = %v, want an APIError", err)
+				}
+			case *url.Error:
+				if !errors.As(err, &target) || errors.Is(err, ErrUnauthorized) {
+					t.Fatalf("error = %v, want a transient url.Error", err)
+				}
+			default:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if got := spotifyClient.Retries(); got != tt.wantRetries {
+				t.Errorf("retries = %d, want %d", got, tt.wantRetries)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Feature is a group of server functionality, each needing its own set of scopes
+type Feature string
+
+const (
+	// FeatureReadOnly covers what's playing, listening history, top items and the profile
+	FeatureReadOnly Feature = "read-only"
+	// FeatureLibrary covers saved items, playlists and followed artists
+	FeatureLibrary Feature = "library"
+	// FeaturePlaybackControl covers pausing, skipping, seeking and queueing
+	FeaturePlaybackControl Feature = "playback-control"
+)
+
+// Features lists every feature, in the order they're usually enabled
+var Features = []Feature{FeatureReadOnly, FeatureLibrary, FeaturePlaybackControl}
+
+// FeatureScopes are the scope presets requested for each feature
+var FeatureScopes = map[Feature][]string{
+	FeatureReadOnly: {
+		"user-read-currently-playing",
+		"user-read-playback-state",
+		"user-read-recently-played",
+		"user-top-read",
+		"user-read-private",
+	},
+	FeatureLibrary: {
+		"user-library-read",
+		"playlist-read-private",
+		"user-follow-read",
+	},
+	FeaturePlaybackControl: {
+		"user-modify-playback-state",
+	},
+}
+
+func ParseFeature(value string) (Feature, error) {
+	feature := Feature(strings.TrimSpace(value))
+	if _, ok := FeatureScopes[feature]; !ok {
+		return "", fmt.Errorf("invalid feature %q", value)
+	}
+	return feature, nil
+}
+
+// ParseFeatures parses a comma separated list of features
+func ParseFeatures(value string) ([]Feature, error) {
+	var features []Feature
+	for _, name := range strings.Split(value, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		feature, err := ParseFeature(name)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(features, feature) {
+			features = append(features, feature)
+		}
+	}
+	return features, nil
+}
+
+// ScopesFor returns the scopes needed by features, without duplicates
+func ScopesFor(features ...Feature) []string {
+	var scopes []string
+	for _, feature := range features {
+		for _, scope := range FeatureScopes[feature] {
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// ResolveScopes expands a comma separated list of feature presets and raw scope names
+func ResolveScopes(value string) []string {
+	var scopes []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		names := []string{name}
+		if preset, ok := FeatureScopes[Feature(name)]; ok {
+			names = preset
+		}
+		for _, scope := range names {
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// MissingScopes returns the scopes features need that weren't granted
+func MissingScopes(granted []string, features ...Feature) []string {
+	var missing []string
+	for _, scope := range ScopesFor(features...) {
+		if !slices.Contains(granted, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+const searchPath = "/search"
+
+type SearchType string
+
+const (
+	SearchTrack    SearchType = "track"
+	SearchArtist   SearchType = "artist"
+	SearchAlbum    SearchType = "album"
+	SearchPlaylist SearchType = "playlist"
+	SearchShow     SearchType = "show"
+	SearchEpisode  SearchType = "episode"
+)
+
+// ParseSearchType validates a search type coming from user input
+func ParseSearchType(value string) (SearchType, bool) {
+	switch searchType := SearchType(value); searchType {
+	case SearchTrack, SearchArtist, SearchAlbum, SearchPlaylist, SearchShow, SearchEpisode:
+		return searchType, true
+	}
+	return "", false
+}
+
+// SearchQuery combines free text with Spotify's field filters
+type SearchQuery struct {
+	Text   string
+	Artist string
+	Album  string
+	Track  string
+	Genre  string
+	ISRC   string
+	// Year is a single year like 1999 or a range like 1990-1999
+	Year string
+}
+
+func (q SearchQuery) String() string {
+	parts := []string{}
+	if text := strings.TrimSpace(q.Text); text != "" {
+		parts = append(parts, text)
+	}
+
+	filters := []struct{ field, value string }{
+		{"artist", q.Artist},
+		{"album", q.Album},
+		{"track", q.Track},
+		{"genre", q.Genre},
+		{"isrc", q.ISRC},
+		{"year", q.Year},
+	}
+	for _, filter := range filters {
+		value := strings.TrimSpace(strings.ReplaceAll(filter.value, `"`, ""))
+		if value == "" {
+			continue
+		}
+		if strings.ContainsAny(value, " \t") {
+			value = `"` + value + `"`
+		}
+		parts = append(parts, filter.field+":"+value)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Search looks up query in the catalogue, use SearchQuery.String to add field filters.
+// Limit, Offset and InMarket apply to every type searched for
+func (s *SpotifyClient) Search(ctx context.Context, query string, types []SearchType, opts ...func(*RequestOptions)) (*SearchResult, error) {
+	sr := &SpotifySearchResult{}
+	options := s.requestOptions(opts)
+
+	typeNames := make([]string, 0, len(types))
+	for _, searchType := range types {
+		typeNames = append(typeNames, string(searchType))
+	}
+
+	params := options.offsetParams()
+	params.Set("q", query)
+	params.Set("type", strings.Join(typeNames, ","))
+	if options.Market != "" {
+		params.Set("market", options.Market)
+	}
+
+	err := s.doRequest(ctx, http.MethodGet, s.endpoint(searchPath), params, nil, sr)
+	if err != nil {
+		return nil, err
+	}
+	return sr.Convert(), nil
+}
@@ -1,37 +1,263 @@
 // Structs that I can unmarshal from Spotify API
 package client
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type SpotifyTrack struct {
+	ID           string            `json:"id"`
+	URI          string            `json:"uri"`
 	Name         string            `json:"name"`
 	Artists      []*SpotifyArtist  `json:"artists"`
+	Album        *SpotifyAlbum     `json:"album"`
+	DurationMs   int               `json:"duration_ms"`
+	Explicit     bool              `json:"explicit"`
+	Popularity   int               `json:"popularity"`
+	PreviewURL   *string           `json:"preview_url"`
+	ExternalIDs  map[string]string `json:"external_ids"`
 	ExternalURLs map[string]string `json:"external_urls"`
 }
 
 type SpotifyArtist struct {
+	ID           string            `json:"id"`
+	URI          string            `json:"uri"`
 	Name         string            `json:"name"`
+	Genres       []string          `json:"genres"`
+	Images       []*SpotifyImage   `json:"images"`
+	Popularity   int               `json:"popularity"`
+	Followers    *SpotifyFollowers `json:"followers"`
 	ExternalURLs map[string]string `json:"external_urls"`
 }
 
+type SpotifyAlbum struct {
+	ID           string            `json:"id"`
+	URI          string            `json:"uri"`
+	Name         string            `json:"name"`
+	AlbumType    string            `json:"album_type"`
+	ReleaseDate  string            `json:"release_date"`
+	TotalTracks  int               `json:"total_tracks"`
+	Images       []*SpotifyImage   `json:"images"`
+	Artists      []*SpotifyArtist  `json:"artists"`
+	ExternalURLs map[string]string `json:"external_urls"`
+}
+
+type SpotifyImage struct {
+	URL    string `json:"url"`
+	Height int    `json:"height"`
+	Width  int    `json:"width"`
+}
+
+type SpotifyFollowers struct {
+	Total int `json:"total"`
+}
+
+type SpotifyEpisode struct {
+	ID           string            `json:"id"`
+	URI          string            `json:"uri"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	DurationMs   int               `json:"duration_ms"`
+	Explicit     bool              `json:"explicit"`
+	ReleaseDate  string            `json:"release_date"`
+	Images       []*SpotifyImage   `json:"images"`
+	Show         *SpotifyShow      `json:"show"`
+	ExternalURLs map[string]string `json:"external_urls"`
+}
+
+type SpotifyShow struct {
+	ID            string            `json:"id"`
+	URI           string            `json:"uri"`
+	Name          string            `json:"name"`
+	Publisher     string            `json:"publisher"`
+	Description   string            `json:"description"`
+	TotalEpisodes int               `json:"total_episodes"`
+	Images        []*SpotifyImage   `json:"images"`
+	ExternalURLs  map[string]string `json:"external_urls"`
+}
+
+// SpotifyItem is either a track or a podcast episode, depending on Type
+type SpotifyItem struct {
+	Type    string
+	Track   *SpotifyTrack
+	Episode *SpotifyEpisode
+}
+
+func (i *SpotifyItem) UnmarshalJSON(data []byte) error {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return err
+	}
+
+	i.Type = header.Type
+	if header.Type == "episode" {
+		i.Episode = &SpotifyEpisode{}
+		return json.Unmarshal(data, i.Episode)
+	}
+	i.Track = &SpotifyTrack{}
+	return json.Unmarshal(data, i.Track)
+}
+
 type SpotifyCurrentlyPlaying struct {
-	Progress int           `json:"progress_ms"`
-	Item     *SpotifyTrack `json:"item"`
+	Progress             int             `json:"progress_ms"`
+	Item                 *SpotifyItem    `json:"item"`
+	IsPlaying            bool            `json:"is_playing"`
+	CurrentlyPlayingType string          `json:"currently_playing_type"`
+	Timestamp            int64           `json:"timestamp"`
+	Context              *SpotifyContext `json:"context"`
+}
+
+type SpotifyQueue struct {
+	CurrentlyPlaying *SpotifyItem   `json:"currently_playing"`
+	Queue            []*SpotifyItem `json:"queue"`
+}
+
+type SpotifyDevice struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Type             string `json:"type"`
+	IsActive         bool   `json:"is_active"`
+	IsPrivateSession bool   `json:"is_private_session"`
+	IsRestricted     bool   `json:"is_restricted"`
+	VolumePercent    *int   `json:"volume_percent"`
+	SupportsVolume   bool   `json:"supports_volume"`
+}
+
+type SpotifyPlaybackState struct {
+	SpotifyCurrentlyPlaying
+	Device       *SpotifyDevice `json:"device"`
+	ShuffleState bool           `json:"shuffle_state"`
+	RepeatState  string         `json:"repeat_state"`
 }
 
 type SpotifyRecentlyPlayed struct {
-	Track    SpotifyTrack `json:"track"`
-	PlayedAt time.Time    `json:"played_at"`
+	Track    SpotifyTrack    `json:"track"`
+	PlayedAt time.Time       `json:"played_at"`
+	Context  *SpotifyContext `json:"context"`
+}
+
+type SpotifyContext struct {
+	Type         string            `json:"type"`
+	URI          string            `json:"uri"`
+	ExternalURLs map[string]string `json:"external_urls"`
+}
+
+type SpotifyCursors struct {
+	After  string `json:"after"`
+	Before string `json:"before"`
 }
 
 type SpotifyRecentlyPlayedTracks struct {
 	RecentlyPlayed []*SpotifyRecentlyPlayed `json:"items"`
+	Next           string                   `json:"next"`
+	Cursors        *SpotifyCursors          `json:"cursors"`
 }
 
 type SpotifyTopTracks struct {
 	Tracks []*SpotifyTrack `json:"items"`
+	Next   string          `json:"next"`
+	Total  int             `json:"total"`
 }
 
 type SpotifyTopArtists struct {
 	Artists []*SpotifyArtist `json:"items"`
+	Next    string           `json:"next"`
+	Total   int              `json:"total"`
+}
+
+// SpotifyPage is one page of a paginated list, the named pages below add their own Convert
+type SpotifyPage[T any] struct {
+	Items []T    `json:"items"`
+	Next  string `json:"next"`
+	Total int    `json:"total"`
+}
+
+type SpotifySavedTrack struct {
+	AddedAt time.Time     `json:"added_at"`
+	Track   *SpotifyTrack `json:"track"`
+}
+
+type SpotifySavedTracks SpotifyPage[*SpotifySavedTrack]
+
+type SpotifySavedAlbum struct {
+	AddedAt time.Time     `json:"added_at"`
+	Album   *SpotifyAlbum `json:"album"`
+}
+
+type SpotifySavedAlbums SpotifyPage[*SpotifySavedAlbum]
+
+type SpotifySavedShow struct {
+	AddedAt time.Time    `json:"added_at"`
+	Show    *SpotifyShow `json:"show"`
+}
+
+type SpotifySavedShows SpotifyPage[*SpotifySavedShow]
+
+type SpotifyUser struct {
+	ID           string            `json:"id"`
+	URI          string            `json:"uri"`
+	DisplayName  string            `json:"display_name"`
+	Images       []*SpotifyImage   `json:"images"`
+	Followers    *SpotifyFollowers `json:"followers"`
+	Country      string            `json:"country"`
+	Product      string            `json:"product"`
+	ExternalURLs map[string]string `json:"external_urls"`
+}
+
+type SpotifyPlaylist struct {
+	ID            string            `json:"id"`
+	URI           string            `json:"uri"`
+	Name          string            `json:"name"`
+	Description   string            `json:"description"`
+	Public        *bool             `json:"public"`
+	Collaborative bool              `json:"collaborative"`
+	SnapshotID    string            `json:"snapshot_id"`
+	Owner         *SpotifyUser      `json:"owner"`
+	Images        []*SpotifyImage   `json:"images"`
+	ExternalURLs  map[string]string `json:"external_urls"`
+	Tracks        *struct {
+		Total int `json:"total"`
+	} `json:"tracks"`
+}
+
+type SpotifyPlaylists SpotifyPage[*SpotifyPlaylist]
+
+type SpotifyPlaylistItem struct {
+	AddedAt time.Time    `json:"added_at"`
+	AddedBy *SpotifyUser `json:"added_by"`
+	IsLocal bool         `json:"is_local"`
+	Item    *SpotifyItem `json:"track"`
+}
+
+type SpotifyPlaylistItems SpotifyPage[*SpotifyPlaylistItem]
+
+type SpotifyFollowedArtists struct {
+	Artists struct {
+		Items   []*SpotifyArtist `json:"items"`
+		Next    string           `json:"next"`
+		Total   int              `json:"total"`
+		Cursors *SpotifyCursors  `json:"cursors"`
+	} `json:"artists"`
+}
+
+type SpotifyArtists struct {
+	Artists []*SpotifyArtist `json:"artists"`
+}
+
+type SpotifyArtistTopTracks struct {
+	Tracks []*SpotifyTrack `json:"tracks"`
+}
+
+type SpotifyAlbums SpotifyPage[*SpotifyAlbum]
+
+type SpotifySearchResult struct {
+	Tracks    *SpotifyPage[*SpotifyTrack]    `json:"tracks"`
+	Artists   *SpotifyPage[*SpotifyArtist]   `json:"artists"`
+	Albums    *SpotifyPage[*SpotifyAlbum]    `json:"albums"`
+	Playlists *SpotifyPage[*SpotifyPlaylist] `json:"playlists"`
+	Shows     *SpotifyPage[*SpotifyShow]     `json:"shows"`
+	Episodes  *SpotifyPage[*SpotifyEpisode]  `json:"episodes"`
 }
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSpotifyItemUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantType    string
+		wantTrack   string
+		wantEpisode string
+		wantNil     bool
+		wantErr     bool
+	}{
+		{"track", `{"type": "track", "id": "t1", "name": "Song"}`, "track", "Song", "", false, false},
+		{"episode", `{"type": "episode", "id": "e1", "name": "Episode", "show": {"name": "Show"}}`, "episode", "", "Episode", false, false},
+		{"missing type is a track", `{"id": "t1", "name": "Song"}`, "", "Song", "", false, false},
+		{"null", `null`, "", "", "", true, false},
+		{"invalid", `{"type": 1}`, "", "", "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var playing SpotifyCurrentlyPlaying
+			err := json.Unmarshal([]byte(`{"item": `+tt.data+`}`), &playing)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			item := playing.Item
+			if tt.wantNil {
+				if item != nil {
+					t.Errorf("item = %+v, want nil", item)
+				}
+				return
+			}
+
+			if item.Type != tt.wantType {
+				t.Errorf("type = %q, want %q", item.Type, tt.wantType)
+			}
+			if tt.wantTrack != "" && (item.Track == nil || item.Track.Name != tt.wantTrack || item.Episode != nil) {
+				t.Errorf("item = %+v, want track %q", item, tt.wantTrack)
+			}
+			if tt.wantEpisode != "" && (item.Episode == nil || item.Episode.Name != tt.wantEpisode || item.Track != nil) {
+				t.Errorf("item = %+v, want episode %q", item, tt.wantEpisode)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tokenSwitch lets the token source be replaced after the client is created,
+// e.g. once an instance started without a refresh token has been logged in
+type tokenSwitch struct {
+	mutex  sync.RWMutex
+	source oauth2.TokenSource
+}
+
+func (t *tokenSwitch) Token() (*oauth2.Token, error) {
+	t.mutex.RLock()
+	source := t.source
+	t.mutex.RUnlock()
+
+	if source == nil {
+		return nil, fmt.Errorf("%w: no refresh token, log in first", ErrUnauthorized)
+	}
+	return source.Token()
+}
+
+func (t *tokenSwitch) set(source oauth2.TokenSource) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.source = source
+}
+
+func (s *SpotifyClient) newTokenSource(token *oauth2.Token) oauth2.TokenSource {
+	// oauth2 refreshes tokens with the client stored in the context
+	ctx := context.Background()
+	if base := s.options.baseHTTPClient(); base != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, base)
+	}
+
+	tokenSource := s.oauthConfig.TokenSource(ctx, token)
+	if s.options.TokenStore != nil {
+		tokenSource = newPersistingTokenSource(tokenSource, s.options.TokenStore, token.RefreshToken)
+	}
+	return tokenSource
+}
+
+// SetToken replaces the client's token, saving it to the token store when
+// there is one so it survives restarts
+func (s *SpotifyClient) SetToken(token *oauth2.Token) error {
+	if token.RefreshToken == "" {
+		return fmt.Errorf("token has no refresh token")
+	}
+
+	if s.options.TokenStore != nil {
+		if err := s.options.TokenStore.Save(token); err != nil {
+			return fmt.Errorf("failed to save token: %w", err)
+		}
+	}
+
+	s.tokenSource.set(s.newTokenSource(token))
+	return nil
+}
+
+// HasToken reports whether the client has a refresh token to make requests with
+func (s *SpotifyClient) HasToken() bool {
+	s.tokenSource.mutex.RLock()
+	defer s.tokenSource.mutex.RUnlock()
+	return s.tokenSource.source != nil
+}
+
+// AuthConfig returns the client's OAuth2 config for the authorization code flow
+func (s *SpotifyClient) AuthConfig(redirectURL string, scopes []string) *oauth2.Config {
+	cfg := *s.oauthConfig
+	cfg.RedirectURL = redirectURL
+	cfg.Scopes = scopes
+	return &cfg
+}
+
+// Token returns the current access token, refreshing it if needed
+func (s *SpotifyClient) Token() (*oauth2.Token, error) {
+	token, err := s.tokenSource.Token()
+	if err != nil {
+		if isTokenRejected(err) {
+			return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+		return nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// TokenStatus describes the token the client is using
+type TokenStatus struct {
+	User *User
+	// Scopes is nil when Spotify didn't report the granted scopes
+	Scopes []string
+	Expiry time.Time
+}
+
+// Status refreshes the token and fetches the user it belongs to. It returns
+// an error wrapping ErrUnauthorized when the refresh token is no longer valid
+func (s *SpotifyClient) Status(ctx context.Context) (*TokenStatus, error) {
+	token, err := s.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TokenStatus{User: user, Expiry: token.Expiry}
+	if scope, ok := token.Extra("scope").(string); ok {
+		status.Scopes = strings.Fields(scope)
+	}
+	return status, nil
+}
+
+// Missing returns the scopes feature needs that weren't granted, nil when the
+// granted scopes aren't known
+func (t *TokenStatus) Missing(feature Feature) []string {
+	if t.Scopes == nil {
+		return nil
+	}
+	return MissingScopes(t.Scopes, feature)
+}
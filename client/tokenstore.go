@@ -0,0 +1,202 @@
+package client
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/ash-xyz/spotify/internal/secrets"
+	"github.com/joho/godotenv"
+	"golang.org/x/oauth2"
+)
+
+const refreshTokenEnv = "SPOTIFY_REFRESH_TOKEN"
+
+// TokenStore persists OAuth2 tokens so a refresh token rotated by Spotify survives restarts.
+// Load returns an error wrapping fs.ErrNotExist when nothing has been stored yet
+type TokenStore interface {
+	Load() (*oauth2.Token, error)
+	Save(token *oauth2.Token) error
+}
+
+func WithTokenStore(store TokenStore) func(*Options) {
+	return func(o *Options) {
+		o.TokenStore = store
+	}
+}
+
+// EnvFileStore keeps the refresh token as SPOTIFY_REFRESH_TOKEN in a .env file,
+// leaving the other variables in it untouched
+type EnvFileStore struct {
+	Path string
+	// Variable replaces SPOTIFY_REFRESH_TOKEN, e.g. to keep several accounts in one file
+	Variable string
+}
+
+func (e *EnvFileStore) Load() (*oauth2.Token, error) {
+	env, err := godotenv.Read(e.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	variable := cmp.Or(e.Variable, refreshTokenEnv)
+	refreshToken := env[variable]
+	if refreshToken == "" {
+		return nil, fmt.Errorf("%s is not set in %s: %w", variable, e.Path, fs.ErrNotExist)
+	}
+	return &oauth2.Token{RefreshToken: refreshToken}, nil
+}
+
+func (e *EnvFileStore) Save(token *oauth2.Token) error {
+	variable := cmp.Or(e.Variable, refreshTokenEnv)
+	return secrets.UpdateEnvFile(e.Path, map[string]string{variable: token.RefreshToken})
+}
+
+// JSONFileStore keeps the whole token, including the access token and its expiry
+type JSONFileStore struct {
+	Path string
+}
+
+func (j *JSONFileStore) Load() (*oauth2.Token, error) {
+	data, err := os.ReadFile(j.Path)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalToken(data)
+}
+
+func (j *JSONFileStore) Save(token *oauth2.Token) error {
+	data, err := marshalToken(token)
+	if err != nil {
+		return err
+	}
+	return secrets.WriteFile(j.Path, data)
+}
+
+// EncryptedFileStore is a JSONFileStore encrypted with AES-256-GCM, Key must be 32 bytes
+type EncryptedFileStore struct {
+	Path string
+	Key  []byte
+}
+
+func (e *EncryptedFileStore) Load() (*oauth2.Token, error) {
+	sealed, err := os.ReadFile(e.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := secrets.Open(e.Key, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", e.Path, err)
+	}
+	return unmarshalToken(data)
+}
+
+func (e *EncryptedFileStore) Save(token *oauth2.Token) error {
+	data, err := marshalToken(token)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := secrets.Seal(e.Key, data)
+	if err != nil {
+		return err
+	}
+	return secrets.WriteFile(e.Path, sealed)
+}
+
+// SecretsFileStore keeps the refresh token in an encrypted secrets file,
+// next to the client credentials written by the auth flow
+type SecretsFileStore struct {
+	Path string
+	Key  secrets.Key
+	// Variable replaces SPOTIFY_REFRESH_TOKEN, e.g. to keep several accounts in one file
+	Variable string
+}
+
+func (s *SecretsFileStore) Load() (*oauth2.Token, error) {
+	values, err := secrets.Load(s.Path, s.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	variable := cmp.Or(s.Variable, refreshTokenEnv)
+	refreshToken := values[variable]
+	if refreshToken == "" {
+		return nil, fmt.Errorf("%s is not set in %s: %w", variable, s.Path, fs.ErrNotExist)
+	}
+	return &oauth2.Token{RefreshToken: refreshToken}, nil
+}
+
+func (s *SecretsFileStore) Save(token *oauth2.Token) error {
+	return secrets.Update(s.Path, s.Key, map[string]string{cmp.Or(s.Variable, refreshTokenEnv): token.RefreshToken})
+}
+
+// storedToken adds the granted scopes, which oauth2.Token only keeps in its unexported extras
+type storedToken struct {
+	*oauth2.Token
+	Scope string `json:"scope,omitempty"`
+}
+
+func marshalToken(token *oauth2.Token) ([]byte, error) {
+	stored := storedToken{Token: token}
+	if scope, ok := token.Extra("scope").(string); ok {
+		stored.Scope = scope
+	}
+	return json.MarshalIndent(stored, "", "  ")
+}
+
+func unmarshalToken(data []byte) (*oauth2.Token, error) {
+	stored := storedToken{Token: &oauth2.Token{}}
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to decode token: %w", err)
+	}
+	if stored.Scope != "" {
+		return stored.Token.WithExtra(map[string]interface{}{"scope": stored.Scope}), nil
+	}
+	return stored.Token, nil
+}
+
+// persistingTokenSource saves the token whenever Spotify hands out a new refresh token
+type persistingTokenSource struct {
+	base  oauth2.TokenSource
+	store TokenStore
+
+	mutex        sync.Mutex
+	refreshToken string
+}
+
+func newPersistingTokenSource(base oauth2.TokenSource, store TokenStore, refreshToken string) *persistingTokenSource {
+	return &persistingTokenSource{
+		base:         base,
+		store:        store,
+		refreshToken: refreshToken,
+	}
+}
+
+func (p *persistingTokenSource) Token() (*oauth2.Token, error) {
+	token, err := p.base.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if token.RefreshToken == "" || token.RefreshToken == p.refreshToken {
+		return token, nil
+	}
+
+	// The access token is still good, so a failed save shouldn't fail the request,
+	// but the new refresh token will be lost on restart
+	if err := p.store.Save(token); err != nil {
+		log.Printf("Error saving rotated refresh token: %v", err)
+		return token, nil
+	}
+	p.refreshToken = token.RefreshToken
+	return token, nil
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestEnvFileStoreSave(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		variable string
+		want     string
+	}{
+		{
+			"new file",
+			"",
+			"",
+			"SPOTIFY_REFRESH_TOKEN=new\n",
+		},
+		{
+			"replaces the token line",
+			"SPOTIFY_CLIENT_ID=id\nSPOTIFY_REFRESH_TOKEN=old\nSPOTIFY_CLIENT_SECRET=secret\n",
+			"",
+			"SPOTIFY_CLIENT_ID=id\nSPOTIFY_REFRESH_TOKEN=new\nSPOTIFY_CLIENT_SECRET=secret\n",
+		},
+		{
+			"appends the token",
+			"SPOTIFY_CLIENT_ID=id\n\n# comment\n",
+			"",
+			"SPOTIFY_CLIENT_ID=id\n\n# comment\nSPOTIFY_REFRESH_TOKEN=new\n",
+		},
+		{
+			"adds a missing trailing newline",
+			"SPOTIFY_CLIENT_ID=id",
+			"",
+			"SPOTIFY_CLIENT_ID=id\nSPOTIFY_REFRESH_TOKEN=new\n",
+		},
+		{
+			"custom variable",
+			"SPOTIFY_REFRESH_TOKEN=main\nSPOTIFY_ACCOUNT_SAM_REFRESH_TOKEN=old\n",
+			"SPOTIFY_ACCOUNT_SAM_REFRESH_TOKEN",
+			"SPOTIFY_REFRESH_TOKEN=main\nSPOTIFY_ACCOUNT_SAM_REFRESH_TOKEN=new\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			store := &EnvFileStore{Path: path, Variable: tt.variable}
+			if err := store.Save(&oauth2.Token{AccessToken: "access", RefreshToken: "new"}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+
+			loaded, err := store.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.RefreshToken != "new" {
+				t.Errorf("Load() refresh token = %q, want %q", loaded.RefreshToken, "new")
+			}
+		})
+	}
+}
+
+func TestEnvFileStoreLoadMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("SPOTIFY_CLIENT_ID=id\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := (&EnvFileStore{Path: path}).Load()
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("error = %v, want fs.ErrNotExist", err)
+	}
+}
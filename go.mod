@@ -3,13 +3,11 @@ module github.com/ash-xyz/spotify
 go 1.24.0
 
 require (
+	github.com/go-chi/chi/v5 v5.2.1
+	github.com/go-chi/cors v1.2.1
 	github.com/joho/godotenv v1.5.1
 	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c
 	golang.org/x/oauth2 v0.29.0
 )
 
-require (
-	github.com/go-chi/chi/v5 v5.2.1 // indirect
-	github.com/go-chi/cors v1.2.1 // indirect
-	golang.org/x/sys v0.1.0 // indirect
-)
+require golang.org/x/sys v0.1.0 // indirect
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit lets each client make requests calls per period, with bursts of
+// up to requests, and answers the rest with 429 Too Many Requests
+func RateLimit(requests int, period time.Duration) func(next http.Handler) http.Handler {
+	limiter := &rateLimiter{
+		burst:   float64(requests),
+		rate:    float64(requests) / period.Seconds(),
+		period:  period,
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+	return limiter.middleware
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	burst  float64
+	rate   float64 // tokens per second
+	period time.Duration
+
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wait, ok := l.allow(clientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allow takes a token from the client's bucket, or reports how long until there is one
+func (l *rateLimiter) allow(client string) (time.Duration, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / l.rate * float64(time.Second)), false
+	}
+	b.tokens--
+	return 0, true
+}
+
+// sweep drops the buckets that have refilled, so the map doesn't grow with every client seen
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.period {
+		return
+	}
+	l.lastSweep = now
+
+	for client, b := range l.buckets {
+		if now.Sub(b.last) >= l.period {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// clientIP is the address Fly's proxy saw the request from when running on
+// Fly, where every request goes through it. Anywhere else the header could be
+// set by the client itself, so the connection's address is used
+func clientIP(r *http.Request) string {
+	if os.Getenv("FLY_APP_NAME") != "" {
+		if ip := r.Header.Get("Fly-Client-IP"); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
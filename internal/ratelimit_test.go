@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	type request struct {
+		after      time.Duration
+		ip         string
+		wantStatus int
+	}
+
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{"burst", []request{
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusTooManyRequests},
+		}},
+		{"clients are limited separately", []request{
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "2.2.2.2", http.StatusOK},
+			{0, "1.1.1.1", http.StatusTooManyRequests},
+		}},
+		{"refills over time", []request{
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusOK},
+			{10 * time.Second, "1.1.1.1", http.StatusTooManyRequests},
+			{10 * time.Second, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusTooManyRequests},
+		}},
+		{"refills up to the burst", []request{
+			{time.Hour, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusOK},
+			{0, "1.1.1.1", http.StatusTooManyRequests},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			// 3 requests a minute is a token every 20 seconds
+			limiter := &rateLimiter{
+				burst:   3,
+				rate:    3 / time.Minute.Seconds(),
+				period:  time.Minute,
+				buckets: map[string]*bucket{},
+				now:     func() time.Time { return now },
+			}
+			handler := limiter.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			for i, req := range tt.requests {
+				now = now.Add(req.after)
+
+				r := httptest.NewRequest(http.MethodGet, "/api/search?q=test", nil)
+				r.RemoteAddr = req.ip + ":1234"
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, r)
+
+				if w.Code != req.wantStatus {
+					t.Fatalf("request %d: status = %d, want %d", i, w.Code, req.wantStatus)
+				}
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d: missing Retry-After", i)
+				}
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name   string
+		flyApp string
+		header string
+		want   string
+	}{
+		{"on Fly", "spotify-ash-xyz", "3.3.3.3", "3.3.3.3"},
+		{"on Fly without the header", "spotify-ash-xyz", "", "1.1.1.1"},
+		{"header ignored off Fly", "", "3.3.3.3", "1.1.1.1"},
+		{"off Fly", "", "", "1.1.1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("FLY_APP_NAME", tt.flyApp)
+
+			r := httptest.NewRequest(http.MethodGet, "/api/search?q=test", nil)
+			r.RemoteAddr = "1.1.1.1:1234"
+			if tt.header != "" {
+				r.Header.Set("Fly-Client-IP", tt.header)
+			}
+
+			if got := clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package secrets
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// Environment variables that configure the encrypted secrets file
+	FileEnv       = "SPOTIFY_SECRETS_FILE"
+	PassphraseEnv = "SPOTIFY_SECRETS_PASSPHRASE"
+	KeyFileEnv    = "SPOTIFY_SECRETS_KEY_FILE"
+
+	DefaultFile = ".secrets.enc"
+
+	// OWASP's recommendation for PBKDF2-HMAC-SHA256
+	pbkdf2Iterations = 600_000
+	saltSize         = 16
+)
+
+// Key unlocks a secrets file, either derived from a passphrase or read from a key file
+type Key struct {
+	passphrase string
+	raw        []byte
+}
+
+func KeyFromPassphrase(passphrase string) Key {
+	return Key{passphrase: passphrase}
+}
+
+// KeyFromFile reads a 32 byte key, stored raw or base64 encoded
+func KeyFromFile(path string) (Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if len(data) == KeySize {
+		return Key{raw: data}, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(raw) != KeySize {
+		return Key{}, fmt.Errorf("key file %s must hold %d raw or base64 encoded bytes", path, KeySize)
+	}
+	return Key{raw: raw}, nil
+}
+
+// KeyFromEnv returns the key configured through SPOTIFY_SECRETS_PASSPHRASE or
+// SPOTIFY_SECRETS_KEY_FILE, ok is false when neither is set
+func KeyFromEnv() (key Key, ok bool, err error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return KeyFromPassphrase(passphrase), true, nil
+	}
+	if keyFile := os.Getenv(KeyFileEnv); keyFile != "" {
+		key, err := KeyFromFile(keyFile)
+		return key, err == nil, err
+	}
+	return Key{}, false, nil
+}
+
+// FileFromEnv returns the secrets file path, SPOTIFY_SECRETS_FILE or .secrets.enc
+func FileFromEnv() string {
+	if path := os.Getenv(FileEnv); path != "" {
+		return path
+	}
+	return DefaultFile
+}
+
+func (k Key) derive(salt []byte) ([]byte, error) {
+	if k.raw != nil {
+		return k.raw, nil
+	}
+	return pbkdf2.Key(sha256.New, k.passphrase, salt, pbkdf2Iterations, KeySize)
+}
+
+// envelope is the on-disk format, the salt is only used for passphrase keys
+type envelope struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt,omitempty"`
+	Data    []byte `json:"data"`
+}
+
+// Load decrypts the key/value pairs in a secrets file
+func Load(path string, key Key) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(raw, &env); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if env.Version != 1 {
+		return nil, fmt.Errorf("unsupported secrets file version %d", env.Version)
+	}
+
+	derived, err := key.derive(env.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := Open(derived, env.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// Save encrypts values into a secrets file only readable by the current user
+func Save(path string, key Key, values map[string]string) error {
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+
+	env := envelope{Version: 1}
+	if key.raw == nil {
+		env.Salt = make([]byte, saltSize)
+		if _, err := rand.Read(env.Salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+	}
+
+	derived, err := key.derive(env.Salt)
+	if err != nil {
+		return err
+	}
+
+	env.Data, err = Seal(derived, plaintext)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data)
+}
+
+// Update sets values in a secrets file, creating it if needed
+func Update(path string, key Key, values map[string]string) error {
+	unlock, err := lockPath(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := Load(path, key)
+	if errors.Is(err, os.ErrNotExist) {
+		existing = map[string]string{}
+	} else if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		existing[name] = value
+	}
+	return Save(path, key, existing)
+}
+
+// LoadEnv sets the environment variables stored in a secrets file, variables
+// that are already set win so the environment can still override them
+func LoadEnv(path string, key Key) error {
+	values, err := Load(path, key)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range values {
+		if os.Getenv(name) == "" {
+			os.Setenv(name, value)
+		}
+	}
+	return nil
+}
+
+// WriteFile replaces path with data, readable only by the current user,
+// so a crash mid-write never leaves a truncated file behind
+func WriteFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// UpdateEnvFile sets values in a .env file, replacing their lines and
+// appending the new ones, the other lines are left untouched
+func UpdateEnvFile(path string, values map[string]string) error {
+	unlock, err := lockPath(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var lines []string
+	found := map[string]bool{}
+	if len(existing) > 0 {
+		for _, line := range strings.Split(strings.TrimRight(string(existing), "\n"), "\n") {
+			if name, _, ok := strings.Cut(line, "="); ok {
+				if value, ok := values[name]; ok {
+					line = name + "=" + value
+					found[name] = true
+				}
+			}
+			lines = append(lines, line)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if !found[name] {
+			lines = append(lines, name+"="+values[name])
+		}
+	}
+
+	return WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"))
+}
@@ -0,0 +1,172 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(make([]byte, KeySize))), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rawKey, err := KeyFromFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := map[string]string{"SPOTIFY_CLIENT_ID": "id", "SPOTIFY_REFRESH_TOKEN": "refresh"}
+
+	tests := []struct {
+		name     string
+		saveKey  Key
+		loadKey  Key
+		wantSalt bool
+		wantErr  bool
+	}{
+		{"passphrase", KeyFromPassphrase("correct horse"), KeyFromPassphrase("correct horse"), true, false},
+		{"wrong passphrase", KeyFromPassphrase("correct horse"), KeyFromPassphrase("battery staple"), true, true},
+		{"key file", rawKey, rawKey, false, false},
+		{"key file for a passphrase file", KeyFromPassphrase("correct horse"), rawKey, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".secrets.enc")
+			if err := Save(path, tt.saveKey, values); err != nil {
+				t.Fatal(err)
+			}
+
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(raw), "refresh") {
+				t.Error("secrets file holds a plaintext value")
+			}
+			if hasSalt := strings.Contains(string(raw), `"salt"`); hasSalt != tt.wantSalt {
+				t.Errorf("salt saved = %v, want %v", hasSalt, tt.wantSalt)
+			}
+			if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+				t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+			}
+
+			got, err := Load(path, tt.loadKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !maps.Equal(got, values) {
+				t.Errorf("Load() = %v, want %v", got, values)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".secrets.enc")
+	key := KeyFromPassphrase("correct horse")
+
+	if err := Update(path, key, map[string]string{"SPOTIFY_CLIENT_ID": "id", "SPOTIFY_REFRESH_TOKEN": "old"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Update(path, key, map[string]string{"SPOTIFY_REFRESH_TOKEN": "new"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"SPOTIFY_CLIENT_ID": "id", "SPOTIFY_REFRESH_TOKEN": "new"}
+	if !maps.Equal(got, want) {
+		t.Errorf("Load() = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateEnvFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		values   map[string]string
+		want     string
+	}{
+		{
+			"new file",
+			"",
+			map[string]string{"SPOTIFY_REFRESH_TOKEN": "refresh"},
+			"SPOTIFY_REFRESH_TOKEN=refresh\n",
+		},
+		{
+			"replaces in place",
+			"SPOTIFY_CLIENT_ID=id\nSPOTIFY_REFRESH_TOKEN=old\nPORT=8080\n",
+			map[string]string{"SPOTIFY_REFRESH_TOKEN": "new"},
+			"SPOTIFY_CLIENT_ID=id\nSPOTIFY_REFRESH_TOKEN=new\nPORT=8080\n",
+		},
+		{
+			"appends missing values in order",
+			"# Spotify\nSPOTIFY_CLIENT_ID=id",
+			map[string]string{"SPOTIFY_REFRESH_TOKEN": "refresh", "SPOTIFY_AUTH_FLOW": "pkce"},
+			"# Spotify\nSPOTIFY_CLIENT_ID=id\nSPOTIFY_AUTH_FLOW=pkce\nSPOTIFY_REFRESH_TOKEN=refresh\n",
+		},
+		{
+			"matches whole names",
+			"SPOTIFY_REFRESH_TOKEN_OLD=keep\nSPOTIFY_REFRESH_TOKEN=old\n",
+			map[string]string{"SPOTIFY_REFRESH_TOKEN": "new"},
+			"SPOTIFY_REFRESH_TOKEN_OLD=keep\nSPOTIFY_REFRESH_TOKEN=new\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := UpdateEnvFile(path, tt.values); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateEnvFileConcurrently(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := "SPOTIFY_ACCOUNT_" + string(rune('A'+i)) + "_REFRESH_TOKEN"
+			if err := UpdateEnvFile(path, map[string]string{name: "refresh"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(got), "\n"); lines != 8 {
+		t.Errorf("file has %d lines, want 8 after concurrent updates:\n%s", lines, got)
+	}
+}
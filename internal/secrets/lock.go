@@ -0,0 +1,41 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// pathLocks serializes read-modify-write updates within the process, keyed
+// by the absolute path, e.g. several accounts saving rotated tokens to one .env
+var pathLocks sync.Map
+
+// lockPath locks path for an update until the returned func is called. Other
+// processes are kept out with a lock on path.lock, where the OS supports it
+func lockPath(path string) (func(), error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	value, _ := pathLocks.LoadOrStore(abs, &sync.Mutex{})
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+
+	file, err := os.OpenFile(abs+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		mutex.Unlock()
+		return nil, err
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		mutex.Unlock()
+		return nil, err
+	}
+
+	return func() {
+		unlockFile(file)
+		file.Close()
+		mutex.Unlock()
+	}, nil
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package secrets
+
+import "os"
+
+// Only the in-process lock is used where flock isn't available
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
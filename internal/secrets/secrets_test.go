@@ -0,0 +1,60 @@
+package secrets
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+	otherKey := bytes.Repeat([]byte{2}, KeySize)
+
+	sealed, err := Seal(key, []byte("refresh-token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 0xff
+
+	tests := []struct {
+		name    string
+		key     []byte
+		sealed  []byte
+		want    string
+		wantErr bool
+	}{
+		{"round trip", key, sealed, "refresh-token", false},
+		{"wrong key", otherKey, sealed, "", true},
+		{"tampered", key, tampered, "", true},
+		{"too short", key, sealed[:4], "", true},
+		{"short key", key[:16], sealed, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Open(tt.key, tt.sealed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Open() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealUsesFreshNonces(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, KeySize)
+
+	first, err := Seal(key, []byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Seal(key, []byte("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(first, second) {
+		t.Error("sealing the same plaintext twice gave the same output")
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/ash-xyz/spotify/client"
+	chi "github.com/go-chi/chi/v5"
+)
+
+// libraryRoutes serve the saved items in the user's library, paged with limit and offset
+func libraryRoutes(spotifyClient *client.SpotifyClient, cache *responseCache) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/tracks", cachedHandler(cache, "saved tracks", 3*time.Minute, func(ctx context.Context, query apiQuery) (interface{}, error) {
+			return spotifyClient.GetSavedTracks(ctx, query.requestOptions()...)
+		}))
+
+		r.Get("/albums", cachedHandler(cache, "saved albums", 3*time.Minute, func(ctx context.Context, query apiQuery) (interface{}, error) {
+			return spotifyClient.GetSavedAlbums(ctx, query.requestOptions()...)
+		}))
+
+		r.Get("/shows", cachedHandler(cache, "saved shows", 3*time.Minute, func(ctx context.Context, query apiQuery) (interface{}, error) {
+			return spotifyClient.GetSavedShows(ctx, query.requestOptions()...)
+		}))
+	}
+}
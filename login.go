@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/ash-xyz/spotify/client"
+	chi "github.com/go-chi/chi/v5"
+	"golang.org/x/oauth2"
+)
+
+const (
+	stateCookie    = "spotify_auth_state"
+	verifierCookie = "spotify_auth_verifier"
+	callbackPath   = "/auth/callback"
+)
+
+// The admin key is posted from a form rather than sent in the query string,
+// which would end up in the request logs
+const loginPage = `<!DOCTYPE html>
+<title>Log in with Spotify</title>
+<form method="post" action="/auth/login">
+<label>Admin key <input type="password" name="key" autocomplete="current-password" required></label>
+<button type="submit">Log in with Spotify</button>
+</form>
+`
+
+// loginRoutes let an instance be authorized from a browser. Only someone with
+// AUTH_ADMIN_KEY can start a login, otherwise anyone could link their account
+func loginRoutes(spotifyClient *client.SpotifyClient, adminKey string, scopes []string) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/login", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(loginPage))
+		})
+
+		r.Post("/login", func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.PostFormValue("key")), []byte(adminKey)) != 1 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			state, err := randomToken()
+			if err != nil {
+				http.Error(w, "Error starting login", http.StatusInternalServerError)
+				return
+			}
+			setAuthCookie(w, r, stateCookie, state)
+
+			cfg := spotifyClient.AuthConfig(redirectURL(r), scopes)
+			opts := []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("show_dialog", "true")}
+			// Without the app secret the PKCE flow is the only option
+			if cfg.ClientSecret == "" {
+				verifier := oauth2.GenerateVerifier()
+				setAuthCookie(w, r, verifierCookie, verifier)
+				opts = append(opts, oauth2.S256ChallengeOption(verifier))
+			}
+
+			http.Redirect(w, r, cfg.AuthCodeURL(state, opts...), http.StatusFound)
+		})
+
+		r.Get("/callback", func(w http.ResponseWriter, r *http.Request) {
+			// The state cookie is only set by a login started with the admin key
+			state, err := r.Cookie(stateCookie)
+			if err != nil || subtle.ConstantTimeCompare([]byte(state.Value), []byte(r.URL.Query().Get("state"))) != 1 {
+				http.Error(w, "State token mismatch", http.StatusBadRequest)
+				return
+			}
+			clearAuthCookie(w, r, stateCookie)
+
+			if authErr := r.URL.Query().Get("error"); authErr != "" {
+				http.Error(w, authErr, http.StatusBadRequest)
+				return
+			}
+
+			code := r.URL.Query().Get("code")
+			if code == "" {
+				http.Error(w, "Couldn't get code required for token exchange", http.StatusBadRequest)
+				return
+			}
+
+			var opts []oauth2.AuthCodeOption
+			if verifier, err := r.Cookie(verifierCookie); err == nil {
+				clearAuthCookie(w, r, verifierCookie)
+				opts = append(opts, oauth2.VerifierOption(verifier.Value))
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+			defer cancel()
+
+			cfg := spotifyClient.AuthConfig(redirectURL(r), scopes)
+			token, err := cfg.Exchange(ctx, code, opts...)
+			if err != nil {
+				log.Printf("Error exchanging code for token: %v", err)
+				http.Error(w, "Error exchanging code for token", http.StatusBadGateway)
+				return
+			}
+
+			if err := spotifyClient.SetToken(token); err != nil {
+				log.Printf("Error setting token: %v", err)
+				http.Error(w, "Error saving token", http.StatusInternalServerError)
+				return
+			}
+			log.Println("Logged in from the browser ✅")
+
+			w.Header().Set("Content-Type", "text/plain")
+			user, err := spotifyClient.GetCurrentUser(ctx)
+			if err != nil {
+				fmt.Fprintln(w, "Authentication successful! Feel free to close this window.")
+				return
+			}
+			fmt.Fprintf(w, "Authenticated as %s! Feel free to close this window.\n", user.DisplayName)
+		})
+	}
+}
+
+// redirectURL is SPOTIFY_SERVER_REDIRECT_URL, or the callback on the host the
+// login was started from. Either way it has to be registered with the Spotify app
+func redirectURL(r *http.Request) string {
+	if redirect := os.Getenv("SPOTIFY_SERVER_REDIRECT_URL"); redirect != "" {
+		return redirect
+	}
+
+	scheme := "http"
+	if isHTTPS(r) {
+		scheme = "https"
+	}
+	return (&url.URL{Scheme: scheme, Host: r.Host, Path: callbackPath}).String()
+}
+
+// Fly terminates TLS at its proxy, which sets X-Forwarded-Proto
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
+
+func setAuthCookie(w http.ResponseWriter, r *http.Request, name string, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/auth",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		// Lax so the cookie is sent on the redirect back from Spotify
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func clearAuthCookie(w http.ResponseWriter, r *http.Request, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     "/auth",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,20 +1,27 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/ash-xyz/spotify/client"
 	"github.com/ash-xyz/spotify/internal"
+	"github.com/ash-xyz/spotify/internal/secrets"
 	chi "github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/joho/godotenv"
@@ -25,32 +32,90 @@ type SpotifyInfo struct {
 	TopSongs         *client.TopTracks            `json:"top_tracks"`
 	CurrentlyPlaying *client.CurrentlyPlaying     `json:"currently_playing"`
 	RecentlyPlayed   *client.RecentlyPlayedTracks `json:"recently_played"`
+	Profile          *client.User                 `json:"profile"`
 }
 
-var (
-	cache      []byte
-	cacheMutex sync.Mutex
-	cacheTime  time.Time
-)
+// apiQuery holds the optional /api query parameters, zero values use the client defaults
+type apiQuery struct {
+	Limit     int
+	Offset    int
+	TimeRange client.TimeRange
+}
 
-func getSpotifyDataAsJSON(client *client.SpotifyClient, ctx context.Context) ([]byte, error) {
-	cacheMutex.Lock()
+func (q apiQuery) cacheKey() string {
+	return fmt.Sprintf("%d:%d:%s", q.Limit, q.Offset, q.TimeRange)
+}
 
-	if cache != nil && time.Since(cacheTime) < 3*time.Minute {
-		result := make([]byte, len(cache))
-		copy(result, cache)
-		cacheMutex.Unlock()
-		return result, nil
+func (q apiQuery) requestOptions() []func(*client.RequestOptions) {
+	var opts []func(*client.RequestOptions)
+	if q.Limit != 0 {
+		opts = append(opts, client.Limit(q.Limit))
+	}
+	if q.Offset != 0 {
+		opts = append(opts, client.Offset(q.Offset))
 	}
+	if q.TimeRange != "" {
+		opts = append(opts, client.Term(q.TimeRange))
+	}
+	return opts
+}
 
-	cacheMutex.Unlock()
+func parseAPIQuery(r *http.Request) (apiQuery, error) {
+	var query apiQuery
 
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > 50 {
+			return query, fmt.Errorf("limit must be between 1 and 50")
+		}
+		query.Limit = n
+	}
+
+	if offset := r.URL.Query().Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 || n > 10000 {
+			return query, fmt.Errorf("offset must be between 0 and 10000")
+		}
+		query.Offset = n
+	}
+
+	if timeRange := r.URL.Query().Get(client.TimeRangeTag); timeRange != "" {
+		parsed, ok := client.ParseTimeRange(timeRange)
+		if !ok {
+			return query, fmt.Errorf("time_range must be one of short_term, medium_term or long_term")
+		}
+		query.TimeRange = parsed
+	}
+
+	return query, nil
+}
+
+// apiCacheTTL is how long /api responses are cached for
+const apiCacheTTL = 3 * time.Minute
+
+func getSpotifyDataAsJSON(client *client.SpotifyClient, cache *responseCache, ctx context.Context, query apiQuery) ([]byte, error) {
+	key := "api:" + query.cacheKey()
+	if data, ok := cache.get(key); ok {
+		return data, nil
+	}
+
+	jsonData, err := fetchSpotifyDataAsJSON(client, ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.set(key, jsonData, apiCacheTTL)
+	return jsonData, nil
+}
+
+func fetchSpotifyDataAsJSON(client *client.SpotifyClient, ctx context.Context, query apiQuery) ([]byte, error) {
+	opts := query.requestOptions()
 	var wg sync.WaitGroup
 
 	spotifyInfo := SpotifyInfo{}
-	wg.Add(4)
+	wg.Add(5)
 
-	errorChannel := make(chan error, 4)
+	errorChannel := make(chan error, 5)
 
 	go func() {
 		defer wg.Done()
@@ -66,7 +131,7 @@ func getSpotifyDataAsJSON(client *client.SpotifyClient, ctx context.Context) ([]
 
 	go func() {
 		defer wg.Done()
-		topArtists, err := client.GetTopArtists(ctx)
+		topArtists, err := client.GetTopArtists(ctx, opts...)
 		if err != nil {
 			spotifyInfo.TopArtists = nil
 			log.Printf("Error fetching top artists: %v", err)
@@ -78,7 +143,7 @@ func getSpotifyDataAsJSON(client *client.SpotifyClient, ctx context.Context) ([]
 
 	go func() {
 		defer wg.Done()
-		topTracks, err := client.GetTopTracks(ctx)
+		topTracks, err := client.GetTopTracks(ctx, opts...)
 		if err != nil {
 			spotifyInfo.TopSongs = nil
 			log.Printf("Error fetching top tracks: %v", err)
@@ -90,7 +155,7 @@ func getSpotifyDataAsJSON(client *client.SpotifyClient, ctx context.Context) ([]
 
 	go func() {
 		defer wg.Done()
-		recentlyPlayed, err := client.GetRecentlyPlayed(ctx)
+		recentlyPlayed, err := client.GetRecentlyPlayed(ctx, opts...)
 		if err != nil {
 			spotifyInfo.RecentlyPlayed = nil
 			log.Printf("Error fetching recently played: %v", err)
@@ -100,6 +165,18 @@ func getSpotifyDataAsJSON(client *client.SpotifyClient, ctx context.Context) ([]
 		}
 	}()
 
+	go func() {
+		defer wg.Done()
+		profile, err := client.GetCurrentUser(ctx)
+		if err != nil {
+			spotifyInfo.Profile = nil
+			log.Printf("Error fetching profile: %v", err)
+			errorChannel <- fmt.Errorf("failed to fetch profile: %w", err)
+		} else {
+			spotifyInfo.Profile = profile
+		}
+	}()
+
 	wg.Wait()
 	close(errorChannel)
 
@@ -107,42 +184,106 @@ func getSpotifyDataAsJSON(client *client.SpotifyClient, ctx context.Context) ([]
 		return nil, err
 	}
 
-	jsonData, err := json.MarshalIndent(spotifyInfo, "", "  ")
-	if err != nil {
-		return nil, err
+	return json.MarshalIndent(spotifyInfo, "", "  ")
+}
+
+func apiHandler(client *client.SpotifyClient, cache *responseCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseAPIQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Retries stop at the deadline, so an outage fails the request instead of holding it
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+
+		data, err := getSpotifyDataAsJSON(client, cache, ctx, query)
+
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("Error retrieving data"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
 	}
+}
 
-	cacheMutex.Lock()
+// cachedHandler serves the JSON of whatever fetch returns, cached per query for ttl
+func cachedHandler(cache *responseCache, name string, ttl time.Duration, fetch func(ctx context.Context, query apiQuery) (interface{}, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseAPIQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-	cache = make([]byte, len(jsonData))
-	copy(cache, jsonData)
-	cacheTime = time.Now()
+		key := name + ":" + query.cacheKey()
+		if data, ok := cache.get(key); ok {
+			w.Write(data)
+			return
+		}
 
-	cacheMutex.Unlock()
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
 
-	return jsonData, nil
+		result, err := fetch(ctx, query)
+		if err != nil {
+			log.Printf("Error fetching %s: %v", name, err)
+			http.Error(w, "Error retrieving "+name, http.StatusInternalServerError)
+			return
+		}
+
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			http.Error(w, "Error retrieving "+name, http.StatusInternalServerError)
+			return
+		}
+
+		cache.set(key, data, ttl)
+		w.Write(data)
+	}
 }
 
-func apiHandler(client *client.SpotifyClient) http.HandlerFunc {
+func queueHandler(client *client.SpotifyClient, cache *responseCache) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		data, err := getSpotifyDataAsJSON(client, context.Background())
+		// The queue changes with every track, so it's only cached briefly
+		if data, ok := cache.get("queue"); ok {
+			w.Write(data)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
 
+		queue, err := client.GetQueue(ctx)
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte("Error retrieving data"))
+			log.Printf("Error fetching queue: %v", err)
+			http.Error(w, "Error retrieving queue", http.StatusInternalServerError)
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
+		data, err := json.MarshalIndent(queue, "", "  ")
+		if err != nil {
+			http.Error(w, "Error retrieving queue", http.StatusInternalServerError)
+			return
+		}
+
+		cache.set("queue", data, 30*time.Second)
 		w.Write(data)
 	}
 }
 
-func assertEnvVariablesExist() error {
+func assertEnvVariablesExist(requireRefreshToken bool) error {
+	// SPOTIFY_CLIENT_SECRET is optional, tokens from the PKCE flow are refreshed without it
 	requiredVars := []string{
 		"SPOTIFY_CLIENT_ID",
-		"SPOTIFY_CLIENT_SECRET",
-		"SPOTIFY_REFRESH_TOKEN",
+	}
+	if requireRefreshToken {
+		requiredVars = append(requiredVars, "SPOTIFY_REFRESH_TOKEN")
 	}
 
 	for _, envVar := range requiredVars {
@@ -153,35 +294,133 @@ func assertEnvVariablesExist() error {
 	return nil
 }
 
-func checkRefreshTokenValidity(ctx context.Context) error {
-	spotifyClient := client.NewSpotifyClient()
-	_, err := spotifyClient.GetCurrentlyPlaying(ctx)
+// checkToken diagnoses the token at startup and returns the features it can
+// serve. Only an invalid token is an error, a feature missing scopes is logged
+// and left out, so an old token doesn't stop the server from starting. The
+// read-only feature is always served, it's what the site depends on
+func checkToken(ctx context.Context, spotifyClient *client.SpotifyClient, features []client.Feature) ([]client.Feature, error) {
+	status, err := spotifyClient.Status(ctx)
 	if err != nil {
-		if strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "401") {
-			return fmt.Errorf("refresh token is invalid or expired")
+		if errors.Is(err, client.ErrUnauthorized) {
+			return nil, fmt.Errorf("refresh token is invalid or expired")
 		}
-
 		log.Printf("Warning: Error checking token validity: %v", err)
+		return features, nil
 	}
-	return nil
+
+	if status.Scopes == nil {
+		log.Println("Spotify didn't report the token's scopes, skipping scope check")
+		return features, nil
+	}
+
+	var usable []client.Feature
+	for _, feature := range features {
+		missing := status.Missing(feature)
+		if len(missing) == 0 {
+			usable = append(usable, feature)
+			continue
+		}
+		if feature == client.FeatureReadOnly {
+			usable = append(usable, feature)
+			log.Printf("Warning: refresh token is missing scopes %s, some %s endpoints may fail", strings.Join(missing, ", "), feature)
+			continue
+		}
+		log.Printf("Warning: refresh token is missing scopes %s, the %s feature is disabled", strings.Join(missing, ", "), feature)
+	}
+	return usable, nil
+}
+
+// tokenStoreFromEnv reads SPOTIFY_TOKEN_STORE, one of env:<path>, json:<path>,
+// encrypted:<path> with a base64 encoded 32 byte SPOTIFY_TOKEN_KEY or secrets:<path>
+// for the encrypted secrets file. fallback is used when it's unset, an empty
+// spec means the token isn't persisted
+func tokenStoreFromEnv(fallback string) (client.TokenStore, error) {
+	return parseTokenStore("SPOTIFY_TOKEN_STORE", cmp.Or(os.Getenv("SPOTIFY_TOKEN_STORE"), fallback), "")
+}
+
+// parseTokenStore parses the spec read from setting, env and secrets stores
+// keep the token in variable, or SPOTIFY_REFRESH_TOKEN when it's empty
+func parseTokenStore(setting string, spec string, variable string) (client.TokenStore, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	kind, path, ok := strings.Cut(spec, ":")
+	if !ok || path == "" {
+		return nil, fmt.Errorf("%s must look like <env|json|encrypted|secrets>:<path>, got %q", setting, spec)
+	}
+
+	switch kind {
+	case "env":
+		return &client.EnvFileStore{Path: path, Variable: variable}, nil
+	case "json":
+		return &client.JSONFileStore{Path: path}, nil
+	case "encrypted":
+		key, err := base64.StdEncoding.DecodeString(os.Getenv("SPOTIFY_TOKEN_KEY"))
+		if err != nil || len(key) != secrets.KeySize {
+			return nil, fmt.Errorf("SPOTIFY_TOKEN_KEY must be %d base64 encoded bytes", secrets.KeySize)
+		}
+		return &client.EncryptedFileStore{Path: path, Key: key}, nil
+	case "secrets":
+		key, ok, err := secrets.KeyFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("secrets token store needs %s or %s", secrets.PassphraseEnv, secrets.KeyFileEnv)
+		}
+		return &client.SecretsFileStore{Path: path, Key: key, Variable: variable}, nil
+	}
+	return nil, fmt.Errorf("unknown %s type %q", setting, kind)
 }
 
-func runServer() error {
-	if err := assertEnvVariablesExist(); err != nil {
+func runServer(defaultTokenStore string) error {
+	tokenStore, err := tokenStoreFromEnv(defaultTokenStore)
+	if err != nil {
+		return err
+	}
+
+	// The refresh token can come from the store instead of the environment, or
+	// from logging in at /auth/login when AUTH_ADMIN_KEY is set
+	adminKey := os.Getenv("AUTH_ADMIN_KEY")
+	if err := assertEnvVariablesExist(tokenStore == nil && adminKey == ""); err != nil {
 		return fmt.Errorf("environment validation failed: %w", err)
 	}
+	if usesPKCE() && tokenStore == nil {
+		log.Println("WARNING: ⚠️ PKCE refresh tokens are rotated on every refresh and no SPOTIFY_TOKEN_STORE is set,")
+		log.Println("WARNING: ⚠️ SPOTIFY_REFRESH_TOKEN will be spent after the first refresh and the next restart will fail to authenticate")
+	}
+
+	retryPolicy := client.DefaultRetryPolicy
+	retryPolicy.OnRetry = func(attempt int, wait time.Duration, err error) {
+		log.Printf("Retrying Spotify request (attempt %d) in %s: %v", attempt, wait, err)
+	}
+
+	clientOptions := []func(*client.Options){client.WithRetryPolicy(retryPolicy)}
+	if tokenStore != nil {
+		clientOptions = append(clientOptions, client.WithTokenStore(tokenStore))
+	}
+	spotifyClient := client.NewSpotifyClient(clientOptions...)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err := checkRefreshTokenValidity(ctx); err != nil {
-		log.Printf("Error: %v", err)
-		log.Println("Please run 'go run auth/main.go' to get a new refresh token")
+	features, err := enabledFeatures()
+	if err != nil {
 		return err
 	}
 
-	spotifyClient := client.NewSpotifyClient()
-	log.Println("Spotify Client Created! ✅")
+	if spotifyClient.HasToken() {
+		features, err = checkToken(ctx, spotifyClient, features)
+		if err != nil {
+			log.Printf("Error: %v", err)
+			log.Println("Please run 'spotify auth status' for details, and 'spotify auth' to get a new refresh token")
+			return err
+		}
+		log.Println("Spotify Client Created! ✅")
+	} else {
+		log.Println("No refresh token yet, log in at /auth/login")
+	}
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
@@ -192,8 +431,56 @@ func runServer() error {
 		w.Write([]byte("This is a little project I'm working on 🎶☕!"))
 	})
 
-	r.Get("/api", apiHandler(spotifyClient))
-	log.Println("API endpoint created! ✅")
+	cache := newResponseCache()
+	r.Get("/api", apiHandler(spotifyClient, cache))
+	r.Get("/api/queue", queueHandler(spotifyClient, cache))
+	// Search is the only route taking free-form input, so it can't be served from a
+	// handful of cache entries and every miss spends the app's Spotify rate limit
+	r.With(internal.RateLimit(30, time.Minute)).Get("/api/search", searchHandler(spotifyClient, cache))
+
+	if slices.Contains(features, client.FeatureLibrary) {
+		r.Route("/api/library", libraryRoutes(spotifyClient, cache))
+
+		publishedPlaylists, err := publishedPlaylistIDs()
+		if err != nil {
+			return err
+		}
+		r.Route("/api/playlists", playlistRoutes(spotifyClient, cache, publishedPlaylists))
+	}
+	log.Println("API endpoints created! ✅")
+
+	accounts, err := accountsFromEnv(defaultTokenStore, client.WithRetryPolicy(retryPolicy))
+	if err != nil {
+		return err
+	}
+	if len(accounts) > 0 {
+		for _, acc := range accounts {
+			// One teammate's revoked token shouldn't take the others down
+			checkCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if _, err := checkToken(checkCtx, acc.client, []client.Feature{client.FeatureReadOnly}); err != nil {
+				log.Printf("Warning: account %s: %v", acc.name, err)
+			}
+			cancel()
+
+			go acc.refreshLoop(context.Background())
+		}
+		r.Route("/api/users", accountRoutes(accounts))
+		log.Printf("Account endpoints created for %d accounts! ✅", len(accounts))
+	}
+
+	if adminKey != "" {
+		if tokenStore == nil {
+			log.Println("No SPOTIFY_TOKEN_STORE set, a token from /auth/login will be lost on restart")
+		}
+		// Limited so the admin key can't be brute forced
+		r.With(internal.RateLimit(10, time.Minute)).Route("/auth", loginRoutes(spotifyClient, adminKey, client.ScopesFor(features...)))
+		log.Println("Login endpoints created! ✅")
+	}
+
+	if slices.Contains(features, client.FeaturePlaybackControl) {
+		r.Route("/player", playerRoutes(spotifyClient, os.Getenv("CONTROL_API_KEY")))
+		log.Println("Player endpoints created! ✅")
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -204,56 +491,155 @@ func runServer() error {
 	return http.ListenAndServe(":"+port, r)
 }
 
+// enabledFeatures reads SPOTIFY_FEATURES, defaulting to read-only.
+// The read-only feature is always on, and playback control needs CONTROL_API_KEY
+func enabledFeatures() ([]client.Feature, error) {
+	features, err := client.ParseFeatures(cmp.Or(os.Getenv("SPOTIFY_FEATURES"), string(client.FeatureReadOnly)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPOTIFY_FEATURES: %w", err)
+	}
+	if !slices.Contains(features, client.FeatureReadOnly) {
+		features = append([]client.Feature{client.FeatureReadOnly}, features...)
+	}
+
+	hasAPIKey := os.Getenv("CONTROL_API_KEY") != ""
+	hasPlayback := slices.Contains(features, client.FeaturePlaybackControl)
+	if hasPlayback && !hasAPIKey {
+		return nil, fmt.Errorf("the %s feature needs CONTROL_API_KEY to be set", client.FeaturePlaybackControl)
+	}
+	if hasAPIKey && !hasPlayback {
+		features = append(features, client.FeaturePlaybackControl)
+	}
+	return features, nil
+}
+
+// loadSecretsFile loads the encrypted secrets file into the environment when a
+// key is configured, reporting whether it did
+func loadSecretsFile() (bool, error) {
+	key, ok, err := secrets.KeyFromEnv()
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := secrets.LoadEnv(secretsFile(), key); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// loadEnv loads the encrypted secrets file and then .env, so the encrypted values
+// win and .env is only required when there's no secrets file
+func loadEnv() (usingSecretsFile bool, err error) {
+	usingSecretsFile, err = loadSecretsFile()
+	if err != nil {
+		return false, err
+	}
+
+	if err := godotenv.Load(envFile()); err != nil && !usingSecretsFile {
+		return false, err
+	}
+	return usingSecretsFile, nil
+}
+
+// localTokenStore is where rotated refresh tokens are saved when running locally
+func localTokenStore(usingSecretsFile bool) string {
+	if usingSecretsFile {
+		return "secrets:" + secretsFile()
+	}
+	return "env:" + envFile()
+}
+
 func local() {
-	if err := godotenv.Load(); err != nil {
-		log.Fatalln("Error loading from .env file")
+	usingSecretsFile, err := loadEnv()
+	if err != nil {
+		log.Fatalf("Error loading from %s: %v", envFile(), err)
 	}
-	if err := runServer(); err != nil {
+
+	if err := runServer(localTokenStore(usingSecretsFile)); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// usesPKCE reports whether the refresh token belongs to the public client,
+// which Spotify rotates on every refresh
+func usesPKCE() bool {
+	return client.AuthFlow(os.Getenv("SPOTIFY_AUTH_FLOW")) == client.AuthFlowPKCE || os.Getenv("SPOTIFY_CLIENT_SECRET") == ""
+}
+
 func setFlySecrets() error {
-	secrets := map[string]string{
+	flySecrets := map[string]string{
 		"SPOTIFY_CLIENT_ID":     os.Getenv("SPOTIFY_CLIENT_ID"),
 		"SPOTIFY_CLIENT_SECRET": os.Getenv("SPOTIFY_CLIENT_SECRET"),
 		"SPOTIFY_REFRESH_TOKEN": os.Getenv("SPOTIFY_REFRESH_TOKEN"),
+		"CONTROL_API_KEY":       os.Getenv("CONTROL_API_KEY"),
+		"PUBLISHED_PLAYLISTS":   os.Getenv("PUBLISHED_PLAYLISTS"),
+		"SPOTIFY_FEATURES":      os.Getenv("SPOTIFY_FEATURES"),
+		"AUTH_ADMIN_KEY":        os.Getenv("AUTH_ADMIN_KEY"),
+		"SPOTIFY_ACCOUNTS":      os.Getenv("SPOTIFY_ACCOUNTS"),
+		"SPOTIFY_AUTH_FLOW":     os.Getenv("SPOTIFY_AUTH_FLOW"),
+		"SPOTIFY_TOKEN_STORE":   os.Getenv("SPOTIFY_TOKEN_STORE"),
+	}
+	// A PKCE token is refreshed without the secret, so it isn't deployed
+	if client.AuthFlow(os.Getenv("SPOTIFY_AUTH_FLOW")) == client.AuthFlowPKCE {
+		delete(flySecrets, "SPOTIFY_CLIENT_SECRET")
+	}
+
+	// Every SPOTIFY_ACCOUNT_<NAME>_* setting goes along with the accounts
+	for _, entry := range os.Environ() {
+		if key, value, ok := strings.Cut(entry, "="); ok && strings.HasPrefix(key, "SPOTIFY_ACCOUNT_") {
+			flySecrets[key] = value
+		}
 	}
 
-	var args []string
-	for key, value := range secrets {
+	return importFlySecrets(flySecrets)
+}
+
+// importFlySecrets sets the non-empty values as Fly.io secrets. They go
+// through stdin so they don't show up in the process list
+func importFlySecrets(values map[string]string) error {
+	var lines []string
+	for key, value := range values {
 		if value != "" {
-			args = append(args, fmt.Sprintf("%s=%s", key, value))
+			lines = append(lines, fmt.Sprintf("%s=%s", key, value))
 		}
 	}
 
-	if len(args) == 0 {
+	if len(lines) == 0 {
 		return fmt.Errorf("no secrets to set")
 	}
 
 	log.Println("Setting Fly.io secrets...")
-	cmd := exec.Command("fly", append([]string{"secrets", "set"}, args...)...)
+	cmd := exec.Command("fly", flyArgs("secrets", "import")...)
+	cmd.Stdin = strings.NewReader(strings.Join(lines, "\n") + "\n")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
 func deploy() {
-	if err := godotenv.Load(".env"); err != nil {
-		log.Fatalln("Error loading from .env file - make sure .env exists with your Spotify credentials")
+	if _, err := loadEnv(); err != nil {
+		log.Fatalf("Error loading credentials - make sure %s or the encrypted secrets file exists: %v", envFile(), err)
 	}
 
-	if err := assertEnvVariablesExist(); err != nil {
+	if err := assertEnvVariablesExist(true); err != nil {
 		log.Fatalf("Missing environment variables: %v", err)
 	}
 
+	// Without a store the server can't keep the rotated token, so the deployed one stops working after a restart
+	if usesPKCE() && os.Getenv("SPOTIFY_TOKEN_STORE") == "" {
+		log.Fatalf("Refusing to deploy a PKCE refresh token without SPOTIFY_TOKEN_STORE, point it at a Fly volume, e.g. SPOTIFY_TOKEN_STORE=json:/data/token.json")
+	}
+
 	if err := setFlySecrets(); err != nil {
 		log.Printf("Warning: Failed to set secrets: %v", err)
 		log.Println("You may need to set them manually if this is your first deployment")
 	}
 
 	log.Println("Deploying to Fly.io...")
-	cmd := exec.Command("fly", "deploy")
+	cmd := exec.Command("fly", flyArgs("deploy")...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
@@ -261,24 +647,14 @@ func deploy() {
 	}
 }
 
-func runAuth(isProduction bool) error {
-	cmd := exec.Command("go", "run", "auth/main.go")
-	if isProduction {
-		cmd.Args = append(cmd.Args, "--prod")
-	} else {
-		cmd.Args = append(cmd.Args, "--local")
-	}
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
-}
-
 func needsAuth(isProduction bool) bool {
+	// Production credentials may only be in the environment, but a profile's
+	// env file has to be loaded before its token can be found
+	usingSecretsFile, err := loadEnv()
 	if isProduction {
 		return os.Getenv("SPOTIFY_REFRESH_TOKEN") == ""
 	}
-
-	if err := godotenv.Load(); err != nil {
+	if err != nil {
 		return true
 	}
 
@@ -287,26 +663,59 @@ func needsAuth(isProduction bool) bool {
 		return true
 	}
 
+	// The server reports bad settings itself, auth won't fix them
+	features, err := enabledFeatures()
+	if err != nil {
+		return false
+	}
+	spotifyClient, err := newLocalClient(usingSecretsFile)
+	if err != nil {
+		return false
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	spotifyClient := client.NewSpotifyClient()
-	_, err := spotifyClient.GetCurrentlyPlaying(ctx)
+	// Locally a new token is the way to get the missing scopes
+	usable, err := checkToken(ctx, spotifyClient, features)
+	return err != nil || len(usable) < len(features)
+}
 
-	return err != nil && (strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "401"))
+// newLocalClient uses the same token store as local mode, so a rotated
+// refresh token is picked up and saved
+func newLocalClient(usingSecretsFile bool) (*client.SpotifyClient, error) {
+	tokenStore, err := tokenStoreFromEnv(localTokenStore(usingSecretsFile))
+	if err != nil {
+		return nil, err
+	}
+	return client.NewSpotifyClient(client.WithTokenStore(tokenStore)), nil
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "auth" {
+		if err := authCommand(os.Args[2:]); err != nil {
+			log.Fatalf("Auth failed: %v", err)
+		}
+		return
+	}
+
 	modeFlag := flag.String("mode", "local", "Mode to run in (deploy, local, run)")
 	resetAuthFlag := flag.Bool("reset-auth", false, "Force new authentication flow")
+	pkceFlag := flag.Bool("pkce", false, "Authenticate with PKCE, without the client secret")
+	noBrowserFlag := flag.Bool("no-browser", false, "Print the auth URL and read the redirect URL from stdin instead of opening a browser")
+	profileFlag := flag.String("profile", "", "Credential profile, e.g. staging reads .env.staging (SPOTIFY_PROFILE)")
 	flag.Parse()
 
+	if err := setProfile(*profileFlag); err != nil {
+		log.Fatal(err)
+	}
+
 	switch *modeFlag {
 	case "deploy":
 		isProduction := true
 		if *resetAuthFlag || needsAuth(isProduction) {
 			log.Println("Setting up authentication for production...")
-			if err := runAuth(isProduction); err != nil {
+			if err := runAuth(isProduction, authOptions(*noBrowserFlag, *pkceFlag)...); err != nil {
 				log.Fatalf("Auth setup failed: %v", err)
 			}
 		}
@@ -315,13 +724,16 @@ func main() {
 		isProduction := false
 		if *resetAuthFlag || needsAuth(isProduction) {
 			log.Println("Setting up authentication for local development...")
-			if err := runAuth(isProduction); err != nil {
+			if err := runAuth(isProduction, authOptions(*noBrowserFlag, *pkceFlag)...); err != nil {
 				log.Fatalf("Auth setup failed: %v", err)
 			}
 		}
 		local()
 	case "run":
-		if err := runServer(); err != nil {
+		if _, err := loadSecretsFile(); err != nil {
+			log.Fatalf("Error loading secrets file: %v", err)
+		}
+		if err := runServer(""); err != nil {
 			log.Fatal(err)
 		}
 	default:
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/ash-xyz/spotify/client"
+	"github.com/ash-xyz/spotify/internal"
+	chi "github.com/go-chi/chi/v5"
+)
+
+var queueableURI = regexp.MustCompile(`^spotify:(track|episode):[A-Za-z0-9]{1,64}$`)
+
+// playerRoutes are private, every request needs the CONTROL_API_KEY as a bearer token
+func playerRoutes(spotifyClient *client.SpotifyClient, apiKey string) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Use(internal.RequireBearerToken(apiKey))
+
+		r.Put("/pause", playerHandler(func(ctx context.Context, r *http.Request) error {
+			return spotifyClient.Pause(ctx, deviceOption(r)...)
+		}))
+
+		r.Put("/play", playerHandler(func(ctx context.Context, r *http.Request) error {
+			return spotifyClient.Resume(ctx, deviceOption(r)...)
+		}))
+
+		r.Post("/next", playerHandler(func(ctx context.Context, r *http.Request) error {
+			return spotifyClient.Next(ctx, deviceOption(r)...)
+		}))
+
+		r.Post("/previous", playerHandler(func(ctx context.Context, r *http.Request) error {
+			return spotifyClient.Previous(ctx, deviceOption(r)...)
+		}))
+
+		r.Put("/seek", playerHandler(func(ctx context.Context, r *http.Request) error {
+			position, err := strconv.Atoi(r.URL.Query().Get("position_ms"))
+			if err != nil || position < 0 {
+				return badRequestError("position_ms must be a non-negative integer")
+			}
+			return spotifyClient.Seek(ctx, time.Duration(position)*time.Millisecond, deviceOption(r)...)
+		}))
+
+		r.Post("/queue", playerHandler(func(ctx context.Context, r *http.Request) error {
+			uri := r.URL.Query().Get("uri")
+			if !queueableURI.MatchString(uri) {
+				return badRequestError("uri must be a spotify:track or spotify:episode URI")
+			}
+			return spotifyClient.AddToQueue(ctx, uri, deviceOption(r)...)
+		}))
+
+		r.Put("/transfer", playerHandler(func(ctx context.Context, r *http.Request) error {
+			deviceID := r.URL.Query().Get("device_id")
+			if deviceID == "" {
+				return badRequestError("device_id is required")
+			}
+			play := r.URL.Query().Get("play") == "true"
+			return spotifyClient.TransferPlayback(ctx, deviceID, play)
+		}))
+	}
+}
+
+func deviceOption(r *http.Request) []func(*client.RequestOptions) {
+	if deviceID := r.URL.Query().Get("device_id"); deviceID != "" {
+		return []func(*client.RequestOptions){client.OnDevice(deviceID)}
+	}
+	return nil
+}
+
+type badRequestError string
+
+func (e badRequestError) Error() string {
+	return string(e)
+}
+
+func playerHandler(command func(ctx context.Context, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+		defer cancel()
+
+		if err := command(ctx, r); err != nil {
+			writeSpotifyError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// writeSpotifyError maps client errors to a response without leaking Spotify's internals
+func writeSpotifyError(w http.ResponseWriter, err error) {
+	var badRequestErr badRequestError
+	var rateLimitErr *client.RateLimitError
+	var apiErr *client.APIError
+
+	// Player errors carry a reason, e.g. PREMIUM_REQUIRED or NO_ACTIVE_DEVICE
+	isAPIErr := errors.As(err, &apiErr)
+	reason := ""
+	if isAPIErr {
+		reason = apiErr.Reason
+	}
+
+	switch {
+	case errors.As(err, &badRequestErr):
+		http.Error(w, badRequestErr.Error(), http.StatusBadRequest)
+		return
+	case errors.As(err, &rateLimitErr):
+		w.Header().Set("Retry-After", strconv.Itoa(int(rateLimitErr.RetryAfter.Seconds())))
+		http.Error(w, "Rate limited by Spotify", http.StatusTooManyRequests)
+	case reason == "PREMIUM_REQUIRED":
+		http.Error(w, "Controlling playback needs Spotify Premium", http.StatusForbidden)
+	case reason == "NO_ACTIVE_DEVICE":
+		http.Error(w, "No active Spotify device", http.StatusNotFound)
+	case reason != "":
+		// e.g. ALREADY_PAUSED or NO_NEXT_TRACK, the player can't do it right now
+		http.Error(w, cmp.Or(apiErr.Message, "Spotify refused the command")+" ("+reason+")", http.StatusConflict)
+	case isAPIErr && apiErr.StatusCode == http.StatusForbidden:
+		http.Error(w, "Spotify refused the command, check the token has the "+cmp.Or(apiErr.Scope, "required")+" scope", http.StatusForbidden)
+	case isAPIErr && apiErr.StatusCode == http.StatusNotFound:
+		http.Error(w, "Spotify couldn't find the device", http.StatusNotFound)
+	default:
+		http.Error(w, "Error controlling playback", http.StatusBadGateway)
+	}
+
+	log.Printf("Error controlling playback: %v", err)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ash-xyz/spotify/client"
+)
+
+func TestWriteSpotifyError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{"bad request", badRequestError("position_ms must be a number"), http.StatusBadRequest},
+		{"rate limited", &client.RateLimitError{APIError: &client.APIError{StatusCode: 429}, RetryAfter: time.Second}, http.StatusTooManyRequests},
+		{"premium required", &client.APIError{StatusCode: 403, Reason: "PREMIUM_REQUIRED"}, http.StatusForbidden},
+		{"no active device", &client.APIError{StatusCode: 404, Reason: "NO_ACTIVE_DEVICE"}, http.StatusNotFound},
+		{"restriction", &client.APIError{StatusCode: 403, Message: "Player command failed: Restriction violated", Reason: "ALREADY_PAUSED"}, http.StatusConflict},
+		{"missing scope", fmt.Errorf("wrapped: %w", &client.APIError{StatusCode: 403, Scope: "user-modify-playback-state"}), http.StatusForbidden},
+		{"unknown device", &client.APIError{StatusCode: 404, Message: "Device not found"}, http.StatusNotFound},
+		{"server error", &client.APIError{StatusCode: 502}, http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeSpotifyError(w, tt.err)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
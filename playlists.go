@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ash-xyz/spotify/client"
+	chi "github.com/go-chi/chi/v5"
+)
+
+var playlistID = regexp.MustCompile(`^[A-Za-z0-9]{1,64}$`)
+
+type publishedPlaylist struct {
+	*client.Playlist
+	Items *client.PlaylistItems `json:"items"`
+}
+
+// publishedPlaylistIDs reads PUBLISHED_PLAYLISTS, a comma separated list of playlist IDs
+func publishedPlaylistIDs() ([]string, error) {
+	var ids []string
+	for id := range strings.SplitSeq(os.Getenv("PUBLISHED_PLAYLISTS"), ",") {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		if !playlistID.MatchString(id) {
+			return nil, fmt.Errorf("invalid playlist ID in PUBLISHED_PLAYLISTS: %q", id)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// playlistRoutes only serve the playlists listed in PUBLISHED_PLAYLISTS
+func playlistRoutes(spotifyClient *client.SpotifyClient, cache *responseCache, published []string) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Get("/", cachedHandler(cache, "playlists", 10*time.Minute, func(ctx context.Context, query apiQuery) (interface{}, error) {
+			playlists := make([]*client.Playlist, len(published))
+			errs := make([]error, len(published))
+
+			var wg sync.WaitGroup
+			for i, id := range published {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					playlists[i], errs[i] = spotifyClient.GetPlaylist(ctx, id)
+				}()
+			}
+			wg.Wait()
+
+			if err := errors.Join(errs...); err != nil {
+				return nil, err
+			}
+			return &client.Playlists{Playlists: playlists, Total: len(playlists)}, nil
+		}))
+
+		r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+			id := chi.URLParam(r, "id")
+			if !slices.Contains(published, id) {
+				http.Error(w, "Playlist not found", http.StatusNotFound)
+				return
+			}
+
+			cachedHandler(cache, "playlist "+id, 10*time.Minute, func(ctx context.Context, query apiQuery) (interface{}, error) {
+				playlist, err := spotifyClient.GetPlaylist(ctx, id)
+				if err != nil {
+					return nil, err
+				}
+
+				items, err := spotifyClient.GetPlaylistItems(ctx, id, query.requestOptions()...)
+				if err != nil {
+					return nil, err
+				}
+
+				return &publishedPlaylist{Playlist: playlist, Items: items}, nil
+			})(w, r)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/ash-xyz/spotify/internal/secrets"
+)
+
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// activeProfile picks the credentials used by every mode, e.g. "staging" reads
+// .env.staging. It's set from --profile or SPOTIFY_PROFILE before anything is loaded
+var activeProfile string
+
+func setProfile(name string) error {
+	if name == "" {
+		name = os.Getenv("SPOTIFY_PROFILE")
+	}
+	if name != "" && !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile %q, use letters, digits, - and _", name)
+	}
+	activeProfile = name
+	return nil
+}
+
+func envFile() string {
+	if activeProfile == "" {
+		return ".env"
+	}
+	return ".env." + activeProfile
+}
+
+// secretsFile is SPOTIFY_SECRETS_FILE, or .secrets.<profile>.enc for a profile
+func secretsFile() string {
+	if activeProfile == "" || os.Getenv(secrets.FileEnv) != "" {
+		return secrets.FileFromEnv()
+	}
+	return ".secrets." + activeProfile + ".enc"
+}
+
+// flyArgs targets the profile's FLY_APP, or the app in fly.toml when it's unset
+func flyArgs(args ...string) []string {
+	if app := os.Getenv("FLY_APP"); app != "" {
+		args = append(args, "--app", app)
+	}
+	return args
+}
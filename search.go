@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ash-xyz/spotify/client"
+)
+
+const (
+	maxSearchLength = 200
+	// Spotify rejects search offsets past this, unlike the other paged endpoints
+	maxSearchOffset = 1000
+)
+
+var (
+	searchYear   = regexp.MustCompile(`^\d{4}(-\d{4})?$`)
+	searchMarket = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+type searchRequest struct {
+	Query  client.SearchQuery
+	Types  []client.SearchType
+	Market string
+}
+
+// cacheKey only uses validated input, so similar searches share an entry
+func (s searchRequest) cacheKey() string {
+	types := make([]string, 0, len(s.Types))
+	for _, searchType := range s.Types {
+		types = append(types, string(searchType))
+	}
+	return fmt.Sprintf("%q:%s:%s", strings.ToLower(s.Query.String()), strings.Join(types, ","), s.Market)
+}
+
+func parseSearchRequest(r *http.Request) (searchRequest, error) {
+	values := r.URL.Query()
+	request := searchRequest{
+		Query: client.SearchQuery{
+			Text:   values.Get("q"),
+			Artist: values.Get("artist"),
+			Album:  values.Get("album"),
+			Track:  values.Get("track"),
+			Genre:  values.Get("genre"),
+			Year:   values.Get("year"),
+		},
+		Market: values.Get("market"),
+	}
+
+	query := request.Query.String()
+	if query == "" {
+		return request, fmt.Errorf("q or a field filter is required")
+	}
+	if utf8.RuneCountInString(query) > maxSearchLength {
+		return request, fmt.Errorf("search must be at most %d characters", maxSearchLength)
+	}
+	if request.Query.Year != "" && !searchYear.MatchString(request.Query.Year) {
+		return request, fmt.Errorf("year must look like 1999 or 1990-1999")
+	}
+	if request.Market != "" && !searchMarket.MatchString(request.Market) {
+		return request, fmt.Errorf("market must be a two letter country code")
+	}
+	if offset := values.Get("offset"); offset != "" {
+		if n, err := strconv.Atoi(offset); err != nil || n < 0 || n > maxSearchOffset {
+			return request, fmt.Errorf("offset must be between 0 and %d", maxSearchOffset)
+		}
+	}
+
+	types := values.Get("type")
+	if types == "" {
+		types = string(client.SearchTrack)
+	}
+	for name := range strings.SplitSeq(types, ",") {
+		searchType, ok := client.ParseSearchType(strings.TrimSpace(name))
+		if !ok {
+			return request, fmt.Errorf("type must be a comma separated list of track, artist, album, playlist, show or episode")
+		}
+		request.Types = append(request.Types, searchType)
+	}
+
+	return request, nil
+}
+
+// searchHandler lets the site resolve free text, e.g. a song request, to Spotify items
+func searchHandler(spotifyClient *client.SpotifyClient, cache *responseCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		request, err := parseSearchRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		cachedHandler(cache, "search "+request.cacheKey(), 10*time.Minute, func(ctx context.Context, query apiQuery) (interface{}, error) {
+			opts := query.requestOptions()
+			if request.Market != "" {
+				opts = append(opts, client.InMarket(request.Market))
+			}
+			return spotifyClient.Search(ctx, request.Query.String(), request.Types, opts...)
+		})(w, r)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseSearchRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"text", "q=daft+punk", false},
+		{"field filter", "artist=daft+punk&year=2001", false},
+		{"missing query", "type=track", true},
+		{"invalid year", "q=x&year=2001-", true},
+		{"invalid market", "q=x&market=gb", true},
+		{"invalid type", "q=x&type=track,song", true},
+		{"last offset", "q=x&offset=1000", false},
+		// Fine for the other endpoints, Spotify rejects it for search
+		{"offset past search's max", "q=x&offset=1001", true},
+		{"negative offset", "q=x&offset=-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSearchRequest(httptest.NewRequest("GET", "/api/search?"+tt.query, nil))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}